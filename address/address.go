@@ -0,0 +1,352 @@
+// Package address validates receive addresses offline, before a trade is
+// created, so that a typo or an address for the wrong chain is caught before
+// any funds move.
+//
+// Validators are keyed by network, since a token such as USDT uses the
+// address format of the chain it lives on. When no network is given, the
+// coin's native chain is assumed. Chains that tell deposits apart by a memo
+// or destination tag describe it through MemoFor.
+//
+// Coins this package has no entry for fall back to the coin registry: its
+// address_format picks a validator and its memo flag a generic memo, so a
+// coin added to the registry is checked without a new release.
+package address
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
+)
+
+// ErrInvalid is matched by every error returned for a malformed address.
+var ErrInvalid = errors.New("invalid address")
+
+// ErrUnsupported is returned when there is no validator for a network.
+// Callers usually let such addresses through unchecked.
+var ErrUnsupported = errors.New("address validation not supported")
+
+var errChecksum = errors.New("checksum mismatch, check for typos")
+
+// Error describes why an address is not valid for a network.
+type Error struct {
+	// Network is the network the address was checked against.
+	Network string
+	// Reason says what is wrong with the address.
+	Reason error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("not a valid %s address: %s", strings.ToUpper(e.Network), e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalid) true.
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+func (e *Error) Unwrap() error {
+	return e.Reason
+}
+
+type validator func(string) error
+
+// validators maps network names, including common aliases, to validators.
+var validators = map[string]validator{}
+
+func register(v validator, networks ...string) {
+	for _, n := range networks {
+		validators[n] = v
+	}
+}
+
+func init() {
+	register(validateBitcoin, "btc", "bitcoin")
+	register(validateLitecoin, "ltc", "litecoin")
+	register(validateBitcoinCash, "bch", "bitcoincash", "bitcoin-cash")
+	register(validateDogecoin, "doge", "dogecoin")
+	register(validateDash, "dash")
+	register(validateEVM,
+		"eth", "ethereum", "erc20",
+		"bsc", "bep20", "bnb",
+		"matic", "pol", "polygon",
+		"arbitrum", "arb",
+		"op", "optimism",
+		"base",
+		"avaxc", "avax", "avalanche",
+		"etc",
+	)
+	register(validateTron, "trx", "tron", "trc20")
+	register(validateSolana, "sol", "solana", "spl")
+	register(validateMonero, "xmr", "monero")
+	register(validateZcash, "zec", "zcash")
+}
+
+// formats maps the address formats named in the coin registry to
+// validators.
+var formats = map[string]validator{
+	"bitcoin":     validateBitcoin,
+	"litecoin":    validateLitecoin,
+	"bitcoincash": validateBitcoinCash,
+	"dogecoin":    validateDogecoin,
+	"dash":        validateDash,
+	"evm":         validateEVM,
+	"tron":        validateTron,
+	"solana":      validateSolana,
+	"monero":      validateMonero,
+	"zcash":       validateZcash,
+}
+
+// validatorFor returns the validator registered for key or, failing that,
+// the one for the address format the coin registry gives key's chain.
+func validatorFor(coin, key string) (validator, bool) {
+	if v, ok := validators[key]; ok {
+		return v, true
+	}
+	c, ok := registryCoin(coin, key)
+	if !ok {
+		return nil, false
+	}
+	v, ok := formats[c.AddressFormat]
+	return v, ok
+}
+
+// registryCoin returns the registry entry of the coin whose own chain is the
+// network key: coin itself, when key is its ticker or default network, or
+// the coin key is named after.
+func registryCoin(coin, key string) (coins.Coin, bool) {
+	onChain := func(c coins.Coin) bool {
+		return key == c.Ticker || (len(c.Networks) > 0 && key == strings.ToLower(c.Networks[0]))
+	}
+	registry := coins.Default()
+	if c, ok := registry.Lookup(coin); ok && onChain(c) {
+		return c, true
+	}
+	if c, ok := registry.Lookup(key); ok && onChain(c) {
+		return c, true
+	}
+	return coins.Coin{}, false
+}
+
+// Supported reports whether addresses on network can be validated.
+func Supported(coin, network string) bool {
+	_, ok := validatorFor(coin, networkKey(coin, network))
+	return ok
+}
+
+// Networks lists the network names that have a validator, sorted.
+func Networks() []string {
+	names := make([]string, 0, len(validators))
+	for n := range validators {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Validate checks that addr is a well-formed mainnet address on network, or on
+// coin's own chain when network is empty. It returns an *Error (matching
+// ErrInvalid) for malformed addresses and ErrUnsupported when the network
+// has no validator.
+func Validate(coin, network, addr string) error {
+	key := networkKey(coin, network)
+	v, ok := validatorFor(coin, key)
+	if !ok {
+		return fmt.Errorf("%w for %s", ErrUnsupported, strings.ToUpper(key))
+	}
+
+	if addr == "" {
+		return &Error{Network: key, Reason: errors.New("address is empty")}
+	}
+	if strings.TrimSpace(addr) != addr {
+		return &Error{Network: key, Reason: errors.New("contains leading or trailing spaces")}
+	}
+	if err := v(addr); err != nil {
+		return &Error{Network: key, Reason: err}
+	}
+	return nil
+}
+
+func networkKey(coin, network string) string {
+	if network == "" {
+		network = coin
+	}
+	return strings.ToLower(strings.TrimSpace(network))
+}
+
+// base58Versions validates a base58check address whose payload is one of the
+// version prefixes followed by a 20-byte hash.
+func base58Versions(s string, versions ...[]byte) error {
+	payload, err := decodeBase58Check(s)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if len(payload) == len(v)+20 && string(payload[:len(v)]) == string(v) {
+			return nil
+		}
+	}
+	return errors.New("wrong address type or network")
+}
+
+// isBech32Like reports whether s looks like a bech32 address with the given
+// human-readable prefix, so that errors can describe the right format.
+func isBech32Like(s, hrp string) bool {
+	return strings.HasPrefix(strings.ToLower(s), hrp+"1")
+}
+
+func validateBitcoin(s string) error {
+	if isBech32Like(s, "bc") {
+		return decodeSegwit("bc", s)
+	}
+	return base58Versions(s, []byte{0x00}, []byte{0x05})
+}
+
+func validateLitecoin(s string) error {
+	if isBech32Like(s, "ltc") {
+		return decodeSegwit("ltc", s)
+	}
+	return base58Versions(s, []byte{0x30}, []byte{0x32}, []byte{0x05})
+}
+
+func validateBitcoinCash(s string) error {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, cashAddrPrefix+":") || strings.HasPrefix(lower, "q") || strings.HasPrefix(lower, "p") {
+		return decodeCashAddr(s)
+	}
+	// Legacy addresses share Bitcoin's format.
+	return base58Versions(s, []byte{0x00}, []byte{0x05})
+}
+
+func validateDogecoin(s string) error {
+	return base58Versions(s, []byte{0x1e}, []byte{0x16})
+}
+
+func validateDash(s string) error {
+	return base58Versions(s, []byte{0x4c}, []byte{0x10})
+}
+
+func validateTron(s string) error {
+	payload, err := decodeBase58Check(s)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != 0x41 {
+		return errors.New("not a Tron address")
+	}
+	return nil
+}
+
+func validateSolana(s string) error {
+	if len(s) < 32 || len(s) > 44 {
+		return errors.New("invalid length")
+	}
+	b, err := decodeBase58(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != 32 {
+		return errors.New("not a 32-byte public key")
+	}
+	return nil
+}
+
+// validateEVM checks a 0x-prefixed 20-byte hex address. Mixed-case addresses
+// must carry a valid EIP-55 checksum; all-lower and all-upper case addresses
+// have none to check.
+func validateEVM(s string) error {
+	if len(s) != 42 || (s[:2] != "0x" && s[:2] != "0X") {
+		return errors.New("must be 0x followed by 40 hex characters")
+	}
+	hex := s[2:]
+	for i := 0; i < len(hex); i++ {
+		c := hex[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return errors.New("must be 0x followed by 40 hex characters")
+		}
+	}
+
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return nil
+	}
+	if eip55(hex) != hex {
+		return errChecksum
+	}
+	return nil
+}
+
+// eip55 returns the checksummed spelling of a 40-character hex address.
+func eip55(hex string) string {
+	lower := strings.ToLower(hex)
+	hash := keccak256([]byte(lower))
+
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && nibble&0x0f >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// validateZcash accepts transparent (t1, t3), Sprout (zc), Sapling (zs) and
+// unified (u1) addresses.
+func validateZcash(s string) error {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "zs1"):
+		hrp, data, variant, err := decodeBech32(s, 128)
+		if err != nil {
+			return err
+		}
+		if hrp != "zs" || variant != bech32 {
+			return errors.New("not a Sapling address")
+		}
+		raw, err := convertBits(data, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		if len(raw) != 43 {
+			return errors.New("invalid length")
+		}
+		return nil
+
+	case strings.HasPrefix(lower, "u1"):
+		// Unified addresses are bech32m without the 90-character limit.
+		// Their contents are scrambled (F4Jumble), so only the checksum and
+		// minimum length are checked.
+		hrp, data, variant, err := decodeBech32(s, 4096)
+		if err != nil {
+			return err
+		}
+		if hrp != "u" || variant != bech32m {
+			return errors.New("not a unified address")
+		}
+		raw, err := convertBits(data, 5, 8, false)
+		if err != nil {
+			return err
+		}
+		if len(raw) < 48 {
+			return errors.New("too short")
+		}
+		return nil
+
+	case strings.HasPrefix(s, "zc"):
+		payload, err := decodeBase58Check(s)
+		if err != nil {
+			return err
+		}
+		if len(payload) != 2+64 || payload[0] != 0x16 || payload[1] != 0x9a {
+			return errors.New("not a Sprout address")
+		}
+		return nil
+	}
+
+	return base58Versions(s, []byte{0x1c, 0xb8}, []byte{0x1c, 0xbd})
+}
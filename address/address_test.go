@@ -0,0 +1,278 @@
+package address
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
+)
+
+// hash20 returns a deterministic 20-byte hash for building test addresses.
+func hash20(seed byte) []byte {
+	return bytes.Repeat([]byte{seed}, 20)
+}
+
+func TestValidate_Valid(t *testing.T) {
+	segwitV0, _ := convertBits(hash20(0x11), 8, 5, true)
+	ltcSegwit := encodeBech32("ltc", append([]byte{0}, segwitV0...), bech32)
+
+	sapling, _ := convertBits(bytes.Repeat([]byte{0x42}, 43), 8, 5, true)
+	unified, _ := convertBits(bytes.Repeat([]byte{0x24}, 64), 8, 5, true)
+
+	moneroKeys := bytes.Repeat([]byte{0x07}, 64)
+	moneroAddr := func(tag byte, extra int) string {
+		payload := append([]byte{tag}, moneroKeys...)
+		payload = append(payload, make([]byte, extra)...)
+		return encodeMoneroBase58(append(payload, keccak256(payload)[:4]...))
+	}
+
+	tests := []struct {
+		network string
+		addr    string
+	}{
+		// Bitcoin: genesis P2PKH, P2SH, BIP 173 P2WPKH and BIP 350 P2TR.
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		{"btc", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"btc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4"},
+		{"btc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"},
+
+		{"ltc", encodeBase58Check(append([]byte{0x30}, hash20(0x01)...))},
+		{"ltc", encodeBase58Check(append([]byte{0x32}, hash20(0x02)...))},
+		{"ltc", ltcSegwit},
+
+		// CashAddr spec example, with and without prefix, and legacy.
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"bch", "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"bch", encodeCashAddr(0x08, hash20(0x03))},
+		{"bch", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu"},
+
+		{"doge", encodeBase58Check(append([]byte{0x1e}, hash20(0x04)...))},
+		{"dash", encodeBase58Check(append([]byte{0x4c}, hash20(0x05)...))},
+
+		// EIP-55 examples, and unchecksummed spellings.
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{"bsc", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},
+		{"matic", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"},
+		{"arbitrum", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb"},
+		{"eth", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+
+		{"trx", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+
+		{"sol", "11111111111111111111111111111111"},
+		{"sol", "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"},
+
+		// The Monero General Fund address, and generated integrated and
+		// subaddresses.
+		{"xmr", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A"},
+		{"xmr", moneroAddr(moneroIntegratedTag, 8)},
+		{"xmr", moneroAddr(moneroSubaddressTag, 0)},
+
+		{"zec", "t1Hsc1LR8yKnbbe3twRp88p6vFfC5t7DLbs"},
+		{"zec", encodeBase58Check(append([]byte{0x1c, 0xb8}, hash20(0x06)...))},
+		{"zec", encodeBase58Check(append([]byte{0x1c, 0xbd}, hash20(0x07)...))},
+		{"zec", encodeBech32("zs", sapling, bech32)},
+		{"zec", encodeBech32("u", unified, bech32m)},
+	}
+
+	for _, tt := range tests {
+		if err := Validate("", tt.network, tt.addr); err != nil {
+			t.Errorf("Validate(%s, %s): unexpected error: %v", tt.network, tt.addr, err)
+		}
+	}
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	tests := []struct {
+		network string
+		addr    string
+	}{
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb"},                             // typo
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},                     // bad checksum
+		{"btc", "bc1qw508d6qejxtdg4y5R3zarvary0c5xw7kv8f3t4"},                     // mixed case
+		{"btc", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},                    // wrong chain
+		{"btc", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},                     // wrong chain
+		{"btc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd"}, // v1 with bech32
+		{"ltc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},                             // bitcoin address
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b"},         // bad checksum
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},                     // bad checksum
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA"},                       // too short
+		{"eth", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00"},                     // no 0x
+		{"trx", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u"},                             // bad checksum
+		{"sol", "0OIl0OIl0OIl0OIl0OIl0OIl0OIl0OIl"},                               // not base58
+		{"sol", "1111111111111111111111111111111"},                                // too short
+		{"xmr", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3B"},
+		{"xmr", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		{"zec", "t1Hsc1LR8yKnbbe3twRp88p6vFfC5t7DLbt"}, // bad checksum
+		{"zec", "zs1invalid"},
+		{"eth", " 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}, // leading space
+		{"eth", ""},
+	}
+
+	for _, tt := range tests {
+		err := Validate("", tt.network, tt.addr)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("Validate(%s, %q): expected ErrInvalid, got %v", tt.network, tt.addr, err)
+		}
+	}
+}
+
+func TestValidate_NetworkFallsBackToCoin(t *testing.T) {
+	if err := Validate("XMR", "", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A"); err != nil {
+		t.Errorf("Expected the coin's own chain to be used, got %v", err)
+	}
+	// USDT on Tron uses Tron addresses.
+	if err := Validate("usdt", "trx", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected an EVM address to be rejected on Tron, got %v", err)
+	}
+}
+
+func TestValidate_Unsupported(t *testing.T) {
+	err := Validate("xyz", "xyz", "anything")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if Supported("xyz", "") {
+		t.Error("Expected xyz to be unsupported")
+	}
+	if !Supported("usdt", "eth") {
+		t.Error("Expected usdt on eth to be supported")
+	}
+}
+
+func TestValidate_RegistryFallback(t *testing.T) {
+	registry, err := coins.Parse([]byte(`{"version": 1, "coins": [
+		{"ticker": "wbtc", "name": "Wrapped Bitcoin", "networks": ["eth"], "decimals": 8, "address_format": "evm"},
+		{"ticker": "xno", "name": "Nano", "networks": ["xno"], "decimals": 30, "memo": true}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	coins.SetDefault(registry)
+	t.Cleanup(func() { coins.SetDefault(nil) })
+
+	if err := Validate("wbtc", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"); err != nil {
+		t.Errorf("Expected the registry's address format to be used, got %v", err)
+	}
+	if err := Validate("wbtc", "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected a Bitcoin address to be rejected for an EVM token, got %v", err)
+	}
+	if m, ok := MemoFor("xno", ""); !ok || m != registryMemo {
+		t.Errorf("Expected the registry's memo flag to be used, got %+v, %v", m, ok)
+	}
+	if err := ValidateMemo("xno", "", "123"); err != nil {
+		t.Errorf("Expected a memo to be accepted, got %v", err)
+	}
+	if _, ok := MemoFor("wbtc", ""); ok {
+		t.Error("Expected wbtc to have no memo")
+	}
+}
+
+// TestRegistryAgrees checks that the embedded coin registry and this
+// package's tables describe the same coins the same way.
+func TestRegistryAgrees(t *testing.T) {
+	registry := coins.Embedded()
+	for _, c := range registry.Coins() {
+		if _, ok := memos[c.Ticker]; ok != c.Memo {
+			t.Errorf("%s: registry memo is %t, memo table entry exists: %t", c.Ticker, c.Memo, ok)
+		}
+		if c.AddressFormat != "" {
+			if _, ok := formats[c.AddressFormat]; !ok {
+				t.Errorf("%s: unknown address format %q", c.Ticker, c.AddressFormat)
+			}
+		}
+	}
+	for network := range memos {
+		if c, ok := registry.Lookup(network); ok && !c.Memo {
+			t.Errorf("%s: has a memo format but the registry says it needs no memo", network)
+		}
+	}
+	for _, ticker := range []string{"xrp", "xlm", "atom", "hbar", "eos", "stx", "ton", "hive", "scrt"} {
+		if c, ok := registry.Lookup(ticker); !ok || !c.Memo {
+			t.Errorf("Expected %s in the registry with a memo, got %+v", ticker, c)
+		}
+	}
+}
+
+func TestError_Message(t *testing.T) {
+	err := Validate("", "eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	if !strings.HasPrefix(err.Error(), "not a valid ETH address: checksum mismatch") {
+		t.Errorf("Unexpected message: %v", err)
+	}
+}
+
+func TestBase58_RoundTrip(t *testing.T) {
+	for _, b := range [][]byte{{}, {0}, {0, 0, 1}, []byte("hello world")} {
+		decoded, err := decodeBase58(encodeBase58(b))
+		if err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if !bytes.Equal(decoded, b) {
+			t.Errorf("round trip of %x gave %x", b, decoded)
+		}
+	}
+}
+
+func TestMoneroBase58_RoundTrip(t *testing.T) {
+	for n := range 20 {
+		b := bytes.Repeat([]byte{0xff}, n)
+		decoded, err := decodeMoneroBase58(encodeMoneroBase58(b))
+		if err != nil {
+			t.Fatalf("decode of %d bytes failed: %v", n, err)
+		}
+		if !bytes.Equal(decoded, b) {
+			t.Errorf("round trip of %x gave %x", b, decoded)
+		}
+	}
+}
+
+func TestMemoFor(t *testing.T) {
+	m, ok := MemoFor("XRP", "")
+	if !ok || m.Name != "destination tag" || !m.Numeric {
+		t.Errorf("Unexpected XRP memo: %+v, %v", m, ok)
+	}
+	if _, ok := MemoFor("usdt", "xlm"); !ok {
+		t.Error("Expected tokens on Stellar to need a memo")
+	}
+	if _, ok := MemoFor("btc", ""); ok {
+		t.Error("Expected BTC to have no memo")
+	}
+}
+
+func TestValidateMemo(t *testing.T) {
+	valid := []struct{ network, memo string }{
+		{"xrp", "4294967295"},
+		{"xlm", "exchange-deposit-123"},
+		{"atom", strings.Repeat("a", 256)},
+		{"btc", ""},
+	}
+	for _, tt := range valid {
+		if err := ValidateMemo("", tt.network, tt.memo); err != nil {
+			t.Errorf("ValidateMemo(%s, %q): unexpected error: %v", tt.network, tt.memo, err)
+		}
+	}
+
+	invalid := []struct{ network, memo string }{
+		{"xrp", "4294967296"}, // does not fit 32 bits
+		{"xrp", "-1"},
+		{"xrp", "abc"},
+		{"xlm", strings.Repeat("a", 29)},
+		{"hbar", " 123"},
+		{"btc", "123"}, // no memo on bitcoin
+	}
+	for _, tt := range invalid {
+		err := ValidateMemo("", tt.network, tt.memo)
+		if !errors.Is(err, ErrInvalidMemo) {
+			t.Errorf("ValidateMemo(%s, %q): expected ErrInvalidMemo, got %v", tt.network, tt.memo, err)
+		}
+	}
+
+	// Chains without a validator or memo format may well use memos.
+	for _, network := range []string{"luna", "bep2"} {
+		if err := ValidateMemo("", network, "123"); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("ValidateMemo(%s): expected ErrUnsupported, got %v", network, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Index = func() [256]int {
+	var idx [256]int
+	for i := range idx {
+		idx[i] = -1
+	}
+	for i, c := range base58Alphabet {
+		idx[c] = i
+	}
+	return idx
+}()
+
+var errBase58 = errors.New("contains characters that are not base58")
+
+// decodeBase58 decodes a Bitcoin-alphabet base58 string. Leading '1's become
+// leading zero bytes.
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		d := base58Index[s[i]]
+		if d < 0 {
+			return nil, errBase58
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// encodeBase58 is the inverse of decodeBase58.
+func encodeBase58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeBase58Check decodes a base58check string and returns the payload
+// (version bytes included) without the checksum.
+func decodeBase58Check(s string) ([]byte, error) {
+	b, err := decodeBase58(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 5 {
+		return nil, errors.New("too short")
+	}
+	payload, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(doubleSHA256(payload)[:4], sum) {
+		return nil, errChecksum
+	}
+	return payload, nil
+}
+
+// encodeBase58Check appends the checksum to payload and encodes it.
+func encodeBase58Check(payload []byte) string {
+	return encodeBase58(append(payload[:len(payload):len(payload)], doubleSHA256(payload)[:4]...))
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
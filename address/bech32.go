@@ -0,0 +1,180 @@
+package address
+
+import (
+	"errors"
+	"strings"
+)
+
+// Bech32 (BIP 173) and bech32m (BIP 350).
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+type bech32Variant int
+
+const (
+	bech32 bech32Variant = iota + 1
+	bech32m
+)
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// decodeBech32 splits s into its human-readable part and 5-bit data,
+// verifies the checksum and reports which variant it uses. maxLen is the
+// longest string accepted; BIP 173 limits addresses to 90 characters.
+func decodeBech32(s string, maxLen int) (string, []byte, bech32Variant, error) {
+	if len(s) > maxLen {
+		return "", nil, 0, errors.New("too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("mixes upper and lower case")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, 0, errors.New("malformed bech32 string")
+	}
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, errors.New("malformed bech32 prefix")
+		}
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+		if d < 0 {
+			return "", nil, 0, errors.New("contains characters that are not bech32")
+		}
+		data = append(data, byte(d))
+	}
+
+	var variant bech32Variant
+	switch bech32Polymod(append(bech32HRPExpand(hrp), data...)) {
+	case bech32Const:
+		variant = bech32
+	case bech32mConst:
+		variant = bech32m
+	default:
+		return "", nil, 0, errChecksum
+	}
+	return hrp, data[:len(data)-6], variant, nil
+}
+
+// encodeBech32 is the inverse of decodeBech32.
+func encodeBech32(hrp string, data []byte, variant bech32Variant) string {
+	constant := uint32(bech32Const)
+	if variant == bech32m {
+		constant = bech32mConst
+	}
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, d := range data {
+		b.WriteByte(bech32Charset[d])
+	}
+	for i := range 6 {
+		b.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// convertBits regroups data from fromBits-wide to toBits-wide values. When
+// pad is false, leftover bits must be zero padding.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc  uint32
+		bits uint
+		out  []byte
+	)
+	maxv := uint32(1)<<toBits - 1
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, errors.New("invalid data")
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
+
+// decodeSegwit validates a segregated witness address for hrp (BIP 173 and
+// BIP 350).
+func decodeSegwit(hrp, s string) error {
+	gotHRP, data, variant, err := decodeBech32(s, 90)
+	if err != nil {
+		return err
+	}
+	if gotHRP != hrp {
+		return errors.New("wrong network prefix " + gotHRP)
+	}
+	if len(data) < 1 {
+		return errors.New("missing witness version")
+	}
+
+	version := data[0]
+	if version > 16 {
+		return errors.New("invalid witness version")
+	}
+	if (version == 0) != (variant == bech32) {
+		// Version 0 uses bech32, later versions bech32m.
+		return errChecksum
+	}
+
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return errors.New("invalid witness program length")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return errors.New("invalid witness program length")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package address
+
+import (
+	"errors"
+	"strings"
+)
+
+// CashAddr, the Bitcoin Cash address format.
+
+const cashAddrPrefix = "bitcoincash"
+
+func cashAddrPolymod(values []byte) uint64 {
+	gen := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	c := uint64(1)
+	for _, d := range values {
+		c0 := c >> 35
+		c = (c&0x07ffffffff)<<5 ^ uint64(d)
+		for i := range 5 {
+			if (c0>>i)&1 == 1 {
+				c ^= gen[i]
+			}
+		}
+	}
+	return c ^ 1
+}
+
+func cashAddrPrefixExpand(prefix string) []byte {
+	out := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		out = append(out, prefix[i]&31)
+	}
+	return append(out, 0)
+}
+
+// cashAddrHashSizes maps the size bits of the version byte to hash lengths in
+// bytes.
+var cashAddrHashSizes = [8]int{20, 24, 28, 32, 40, 48, 56, 64}
+
+// decodeCashAddr validates a CashAddr address. The prefix may be omitted.
+func decodeCashAddr(s string) error {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return errors.New("mixes upper and lower case")
+	}
+	s = strings.ToLower(s)
+
+	prefix, payload, found := strings.Cut(s, ":")
+	if !found {
+		prefix, payload = cashAddrPrefix, s
+	}
+	if prefix != cashAddrPrefix {
+		return errors.New("wrong network prefix " + prefix)
+	}
+
+	data := make([]byte, 0, len(payload))
+	for i := 0; i < len(payload); i++ {
+		d := strings.IndexByte(bech32Charset, payload[i])
+		if d < 0 {
+			return errors.New("contains characters that are not valid in CashAddr")
+		}
+		data = append(data, byte(d))
+	}
+	if len(data) < 8+2 {
+		return errors.New("too short")
+	}
+	if cashAddrPolymod(append(cashAddrPrefixExpand(prefix), data...)) != 0 {
+		return errChecksum
+	}
+
+	decoded, err := convertBits(data[:len(data)-8], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	version := decoded[0]
+	if version&0x80 != 0 {
+		return errors.New("invalid version byte")
+	}
+	if len(decoded)-1 != cashAddrHashSizes[version&0x07] {
+		return errors.New("hash length does not match version byte")
+	}
+	return nil
+}
+
+// encodeCashAddr builds a CashAddr address from a version byte and hash.
+func encodeCashAddr(version byte, hash []byte) string {
+	data, _ := convertBits(append([]byte{version}, hash...), 8, 5, true)
+	values := append(cashAddrPrefixExpand(cashAddrPrefix), data...)
+	mod := cashAddrPolymod(append(values, make([]byte, 8)...))
+
+	var b strings.Builder
+	b.WriteString(cashAddrPrefix + ":")
+	for _, d := range data {
+		b.WriteByte(bech32Charset[d])
+	}
+	for i := range 8 {
+		b.WriteByte(bech32Charset[(mod>>(5*(7-i)))&31])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMemo is matched by errors for a malformed memo or destination
+// tag.
+var ErrInvalidMemo = errors.New("invalid memo")
+
+// Memo describes the memo that a chain attaches to transfers. Exchanges and
+// custodial wallets share one deposit address between many users and tell
+// deposits apart by it, so leaving it out can lose the funds.
+type Memo struct {
+	// Name is what the chain calls the memo, e.g. "destination tag".
+	Name string
+	// Numeric is set when the memo must be an unsigned integer.
+	Numeric bool
+	// Bits limits a numeric memo's size.
+	Bits int
+	// MaxLen limits a text memo's length in bytes.
+	MaxLen int
+}
+
+// memos maps network names, including common aliases, to their memo format.
+var memos = map[string]Memo{}
+
+// registryMemo is the memo format assumed for coins that only the coin
+// registry says need a memo.
+var registryMemo = Memo{Name: "memo", MaxLen: 256}
+
+func registerMemo(m Memo, networks ...string) {
+	for _, n := range networks {
+		memos[n] = m
+	}
+}
+
+func init() {
+	registerMemo(Memo{Name: "destination tag", Numeric: true, Bits: 32}, "xrp", "ripple")
+	registerMemo(Memo{Name: "memo", MaxLen: 28}, "xlm", "stellar")
+	registerMemo(Memo{Name: "memo", MaxLen: 256}, "atom", "cosmos", "kava", "osmo", "osmosis", "scrt", "secret")
+	registerMemo(Memo{Name: "memo", MaxLen: 100}, "hbar", "hedera")
+	registerMemo(Memo{Name: "memo", MaxLen: 256}, "eos")
+	registerMemo(Memo{Name: "memo", MaxLen: 34}, "stx", "stacks")
+	registerMemo(Memo{Name: "comment", MaxLen: 120}, "ton")
+	registerMemo(Memo{Name: "memo", MaxLen: 2048}, "hive")
+}
+
+// MemoFor reports the memo format used on network, or on coin's own chain
+// when network is empty. ok is false for chains without memos.
+func MemoFor(coin, network string) (m Memo, ok bool) {
+	return memoFor(coin, networkKey(coin, network))
+}
+
+func memoFor(coin, key string) (Memo, bool) {
+	if m, ok := memos[key]; ok {
+		return m, true
+	}
+	if c, ok := registryCoin(coin, key); ok && c.Memo {
+		return registryMemo, true
+	}
+	return Memo{}, false
+}
+
+// ValidateMemo checks memo against the format of network, or of coin's own
+// chain when network is empty. An empty memo is always valid, since whether
+// one is needed depends on the receiving wallet. A memo for a chain known not
+// to use one, because its addresses are validated but it has no memo format,
+// is an error, as the provider would ignore it. For chains this package knows
+// nothing about it returns ErrUnsupported, and callers usually let the memo
+// through unchecked.
+func ValidateMemo(coin, network, memo string) error {
+	if memo == "" {
+		return nil
+	}
+	key := networkKey(coin, network)
+	m, ok := memoFor(coin, key)
+	if !ok {
+		if _, known := validatorFor(coin, key); !known {
+			return fmt.Errorf("%w: no memo rules for %s", ErrUnsupported, strings.ToUpper(key))
+		}
+		return fmt.Errorf("%w: %s transfers do not carry a memo", ErrInvalidMemo, strings.ToUpper(key))
+	}
+	if strings.TrimSpace(memo) != memo {
+		return fmt.Errorf("%w: %s %s contains leading or trailing spaces", ErrInvalidMemo, strings.ToUpper(key), m.Name)
+	}
+	if m.Numeric {
+		if _, err := strconv.ParseUint(memo, 10, m.Bits); err != nil {
+			return fmt.Errorf("%w: %s %s must be a whole number below %d", ErrInvalidMemo, strings.ToUpper(key), m.Name, uint64(1)<<m.Bits)
+		}
+		return nil
+	}
+	if len(memo) > m.MaxLen {
+		return fmt.Errorf("%w: %s %s is longer than %d bytes", ErrInvalidMemo, strings.ToUpper(key), m.Name, m.MaxLen)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package address
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Monero addresses use their own base58 variant: the data is split into
+// 8-byte blocks, each encoded as exactly 11 characters, and the last, shorter
+// block into as few characters as it needs.
+
+const moneroFullBlock = 8
+
+// moneroBlockSizes maps a block's byte length to its encoded length.
+var moneroBlockSizes = [moneroFullBlock + 1]int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+// Mainnet network tags.
+const (
+	moneroStandardTag   = 18
+	moneroIntegratedTag = 19
+	moneroSubaddressTag = 42
+)
+
+// decodeMoneroBase58 decodes Monero's block-wise base58.
+func decodeMoneroBase58(s string) ([]byte, error) {
+	fullEncoded := moneroBlockSizes[moneroFullBlock]
+	var out []byte
+	for len(s) > 0 {
+		n := min(len(s), fullEncoded)
+		block := s[:n]
+		s = s[n:]
+
+		size := -1
+		for i, encoded := range moneroBlockSizes {
+			if encoded == len(block) {
+				size = i
+				break
+			}
+		}
+		if size < 0 {
+			return nil, errors.New("invalid length")
+		}
+
+		v := new(big.Int)
+		for i := 0; i < len(block); i++ {
+			d := base58Index[block[i]]
+			if d < 0 {
+				return nil, errBase58
+			}
+			v.Mul(v, big.NewInt(58))
+			v.Add(v, big.NewInt(int64(d)))
+		}
+		if v.BitLen() > size*8 {
+			return nil, errors.New("invalid base58 block")
+		}
+		out = append(out, v.FillBytes(make([]byte, size))...)
+	}
+	return out, nil
+}
+
+// encodeMoneroBase58 is the inverse of decodeMoneroBase58.
+func encodeMoneroBase58(b []byte) string {
+	var out []byte
+	for len(b) > 0 {
+		n := min(len(b), moneroFullBlock)
+		v := new(big.Int).SetBytes(b[:n])
+		b = b[n:]
+
+		block := make([]byte, moneroBlockSizes[n])
+		mod := new(big.Int)
+		for i := len(block) - 1; i >= 0; i-- {
+			v.DivMod(v, big.NewInt(58), mod)
+			block[i] = base58Alphabet[mod.Int64()]
+		}
+		out = append(out, block...)
+	}
+	return string(out)
+}
+
+func keccak256(b []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// validateMonero checks a mainnet standard, integrated or subaddress address.
+func validateMonero(s string) error {
+	b, err := decodeMoneroBase58(s)
+	if err != nil {
+		return err
+	}
+	if len(b) < 5 {
+		return errors.New("too short")
+	}
+
+	payload, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(keccak256(payload)[:4], sum) {
+		return errChecksum
+	}
+
+	// Tag, 32-byte public spend key, 32-byte public view key, and for
+	// integrated addresses an 8-byte payment ID.
+	var want int
+	switch payload[0] {
+	case moneroStandardTag, moneroSubaddressTag:
+		want = 1 + 32 + 32
+	case moneroIntegratedTag:
+		want = 1 + 32 + 32 + 8
+	default:
+		return errors.New("not a mainnet address")
+	}
+	if len(payload) != want {
+		return errors.New("invalid length")
+	}
+	return nil
+}
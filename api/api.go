@@ -2,12 +2,7 @@ package api
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/url"
 	"os"
-	"slices"
-	"strings"
 	"time"
 )
 
@@ -19,6 +14,7 @@ type Estimate struct {
 	ExchangeName  string  `json:"Exchange"`
 	ReceiveAmount float64 `json:"Amount"`
 	MinAmount     float64 `json:"MinAmount"`
+	MaxAmount     float64 `json:"MaxAmount"`
 	KYCScore      int     `json:"KYCScore"`
 	Network1      string
 	Network2      string
@@ -27,7 +23,17 @@ type Estimate struct {
 	SendAmount    float64
 	Address       string
 	ImageURL      string
-	TradeValueUSD float64
+	// TradeValue is what ReceiveAmount is worth in the client's currency,
+	// and PriceSource the price source it was worked out with. PriceSource
+	// is empty when the price is not known; see Valued.
+	TradeValue  Money
+	PriceSource string
+	// RateType says whether the rate is locked. RateID identifies a fixed
+	// rate when creating the trade, and ExpiresAt is when it stops being
+	// honored.
+	RateType  RateType  `json:"RateType"`
+	RateID    string    `json:"RateId"`
+	ExpiresAt time.Time `json:"ExpiresAt"`
 }
 
 type TransactionResponse struct {
@@ -44,12 +50,16 @@ type Transaction struct {
 	Id             string    `json:"Id,omitempty"`
 	SendAmount     float64   `json:"SendAmount,omitempty"`
 	Track          string    `json:"Track,omitempty"`
-	Status         string    `json:"Status,omitempty"`
+	Status         TxStatus  `json:"Status,omitempty"`
 	KYC            string    `json:"KYC,omitempty"`
 	Token          string    `json:"Token,omitempty"`
 	Done           bool      `json:"Done,omitempty"`
 	CGID           string    `json:"CGID,omitempty"`
 	CreatedAt      time.Time `json:"CreatedAt,omitempty"`
+	RefundAddress  string    `json:"RefundAddress,omitempty"`
+	RefundMemo     string    `json:"RefundMemo,omitempty"`
+	ExtraID        string    `json:"ExtraId,omitempty"`
+	RateType       RateType  `json:"RateType,omitempty"`
 }
 
 func init() {
@@ -67,133 +77,162 @@ func GetAPIKey() string {
 	return API_KEY
 }
 
-func FetchEstimateFromAPI(ctx context.Context, coin1, coin2 string, amount float64, best bool, network1, network2 string) ([]Estimate, error) {
-	params := url.Values{}
-	params.Set("coin1", coin1)
-	params.Set("coin2", coin2)
-	params.Set("amount", fmt.Sprintf("%f", amount))
-	params.Set("network1", network1)
-	params.Set("network2", network2)
-	if best {
-		params.Set("best", "true")
-	}
-
-	requestURL := fmt.Sprintf("https://%s/estimate?%s", URL, params.Encode())
-
-	data, err := SendRequestWithContext(ctx, requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch estimate: %w", err)
-	}
-
-	type RatesWrapper struct {
-		Results         []Estimate `json:"Results"`
-		Min             float64    `json:"Min"`
-		TradeValue_fiat float64    `json:"TradeValue_fiat"`
-		TradeValue_btc  float64    `json:"TradeValue_btc"`
-	}
-
-	type ApiResponse struct {
-		Min   float64      `json:"min"`
-		Rates RatesWrapper `json:"rates"`
-	}
-
-	var result ApiResponse
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal estimate response: %w", err)
-	}
+// EstimateRequest describes the swap to quote.
+type EstimateRequest struct {
+	Coin1    string
+	Coin2    string
+	Amount   float64
+	Best     bool
+	Network1 string
+	Network2 string
+	// Fixed asks for fixed-rate quotes only.
+	Fixed bool
+	// ReceiveAmount, if set, asks for a reverse quote: the amount of Coin1
+	// each exchange needs to pay out ReceiveAmount of Coin2. Amount is then
+	// only a hint to start the search from and may be zero.
+	ReceiveAmount float64
+}
 
-	priceService := NewPriceService()
-	coin2USDPrice, err := priceService.GetPrice(ctx, coin2)
-	if err != nil {
-		coin2USDPrice = 0
-	}
+// TradeRequest describes the trade to create with a partner exchange.
+type TradeRequest struct {
+	Coin1    string
+	Coin2    string
+	Amount   float64
+	Address  string
+	Partner  string
+	Network1 string
+	Network2 string
+	// RefundAddress and RefundMemo say where the deposit is returned if
+	// the swap fails. Both are optional.
+	RefundAddress string
+	RefundMemo    string
+	// ExtraID is the memo or destination tag for Address, if its chain
+	// uses one.
+	ExtraID string
+	// RateID locks the trade to a fixed-rate quote from Estimate. The
+	// provider then pays exactly the quoted amount or refuses the trade.
+	RateID string
+	// IdempotencyKey, if set, is sent with the request so the API can
+	// recognize repeats, which makes it safe to retry. Without it trade
+	// creation is attempted only once.
+	IdempotencyKey string
+}
 
-	estimates := populateEstimates(result.Rates.Results, coin1, coin2, amount, network1, network2, coin2USDPrice)
-	return estimates, nil
+// FetchEstimateFromAPI quotes a swap using the package-level API key and HTTP
+// client. New code should use Client.Estimate.
+func FetchEstimateFromAPI(ctx context.Context, coin1, coin2 string, amount float64, best bool, network1, network2 string) ([]Estimate, error) {
+	return defaultClient().Estimate(ctx, EstimateRequest{
+		Coin1:    coin1,
+		Coin2:    coin2,
+		Amount:   amount,
+		Best:     best,
+		Network1: network1,
+		Network2: network2,
+	})
 }
 
-func populateEstimates(estimates []Estimate, coin1, coin2 string, amount float64, network1, network2 string, coin2USDPrice float64) []Estimate {
+func populateEstimates(estimates []Estimate, coin1, coin2 string, amount float64, network1, network2 string, coin2Price Money, priceSource string) []Estimate {
 	for i := range estimates {
 		estimates[i].Coin1 = coin1
 		estimates[i].Coin2 = coin2
-		estimates[i].SendAmount = amount
+		if estimates[i].SendAmount == 0 {
+			estimates[i].SendAmount = amount
+		}
 		estimates[i].Network1 = network1
 		estimates[i].Network2 = network2
-		estimates[i].TradeValueUSD = estimates[i].ReceiveAmount * coin2USDPrice
+		estimates[i].TradeValue = Money{Amount: estimates[i].ReceiveAmount * coin2Price.Amount, Currency: coin2Price.Currency}
+		estimates[i].PriceSource = priceSource
+		estimates[i].RateType = ParseRateType(string(estimates[i].RateType))
 	}
-	slices.SortFunc(estimates, func(a, b Estimate) int {
-		if a.ReceiveAmount > b.ReceiveAmount {
-			return -1
-		}
-		if a.ReceiveAmount < b.ReceiveAmount {
-			return 1
-		}
-		return 0
-	})
 	return estimates
 }
 
+// CreateTradeFromAPI creates a trade using the package-level API key and HTTP
+// client. New code should use Client.CreateTrade.
 func CreateTradeFromAPI(ctx context.Context, coin1, coin2 string, amount float64, address, partner string, network1, network2 string) (Transaction, error) {
-	params := url.Values{}
-	params.Set("coin1", coin1)
-	params.Set("coin2", coin2)
-	params.Set("amount", fmt.Sprintf("%f", amount))
-	params.Set("partner", partner)
-	params.Set("address", address)
-	params.Set("network1", network1)
-	params.Set("network2", network2)
-
-	requestURL := fmt.Sprintf("https://%s/swap?%s", URL, params.Encode())
-
-	data, err := SendRequestWithContext(ctx, requestURL)
-	if err != nil {
-		return Transaction{}, fmt.Errorf("failed to create trade: %w", err)
-	}
-
-	var result TransactionResponse
-	if err := json.Unmarshal(data, &result); err != nil {
-		return Transaction{}, fmt.Errorf("failed to unmarshal trade response: %w", err)
-	}
-
-	transaction := result.Transaction
-	return transaction, nil
+	return defaultClient().CreateTrade(ctx, TradeRequest{
+		Coin1:    coin1,
+		Coin2:    coin2,
+		Amount:   amount,
+		Address:  address,
+		Partner:  partner,
+		Network1: network1,
+		Network2: network2,
+	})
 }
 
+// TrackTxFromAPI refreshes t using the package-level API key and HTTP client.
+// New code should use Client.Track.
 func TrackTxFromAPI(ctx context.Context, t Transaction) (Transaction, error) {
-	requestURL := fmt.Sprintf("https://%s/transaction?id=%s", URL, strings.ToLower(t.Provider))
-
-	data, err := SendRequestWithContext(ctx, requestURL)
-	if err != nil {
-		return t, fmt.Errorf("failed to track transaction: %w", err)
-	}
-
-	var responseMap map[string]any
-	if err := json.Unmarshal(data, &responseMap); err != nil {
-		return t, fmt.Errorf("failed to unmarshal track response: %w", err)
-	}
-
-	status, ok := responseMap["status"].(string)
-	if !ok {
-		return t, fmt.Errorf("status field is missing or not a string")
-	}
-	t.Status = status
-
-	return t, nil
+	return defaultClient().Track(ctx, t)
 }
 
+// GetTransactionFromAPI fetches a transaction using the package-level API key
+// and HTTP client. New code should use Client.GetTransaction.
 func GetTransactionFromAPI(ctx context.Context, id string) (Transaction, error) {
-	requestURL := fmt.Sprintf("https://%s/transaction?id=%s", URL, id)
+	return defaultClient().GetTransaction(ctx, id)
+}
 
-	data, err := SendRequestWithContext(ctx, requestURL)
-	if err != nil {
-		return Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+// Merge copies the non-empty fields of src onto t.
+func (t *Transaction) Merge(src Transaction) {
+	if src.Coin1 != "" {
+		t.Coin1 = src.Coin1
 	}
-
-	var result map[string]Transaction
-	if err := json.Unmarshal(data, &result); err != nil {
-		return Transaction{}, fmt.Errorf("failed to unmarshal transaction response: %w", err)
+	if src.Coin2 != "" {
+		t.Coin2 = src.Coin2
+	}
+	if src.Network1 != "" {
+		t.Network1 = src.Network1
+	}
+	if src.Network2 != "" {
+		t.Network2 = src.Network2
+	}
+	if src.Address != "" {
+		t.Address = src.Address
+	}
+	if src.EstimateAmount != 0 {
+		t.EstimateAmount = src.EstimateAmount
+	}
+	if src.Provider != "" {
+		t.Provider = src.Provider
+	}
+	if src.Id != "" {
+		t.Id = src.Id
+	}
+	if src.SendAmount != 0 {
+		t.SendAmount = src.SendAmount
+	}
+	if src.Track != "" {
+		t.Track = src.Track
+	}
+	if src.Status != "" {
+		t.Status = src.Status
+	}
+	if src.KYC != "" {
+		t.KYC = src.KYC
+	}
+	if src.Token != "" {
+		t.Token = src.Token
+	}
+	if src.RefundAddress != "" {
+		t.RefundAddress = src.RefundAddress
+	}
+	if src.RefundMemo != "" {
+		t.RefundMemo = src.RefundMemo
+	}
+	if src.ExtraID != "" {
+		t.ExtraID = src.ExtraID
+	}
+	if src.RateType != "" {
+		t.RateType = src.RateType
+	}
+	if src.Done {
+		t.Done = true
+	}
+	if src.CGID != "" {
+		t.CGID = src.CGID
+	}
+	if !src.CreatedAt.IsZero() {
+		t.CreatedAt = src.CreatedAt
 	}
-
-	transaction := result["transaction"]
-	return transaction, nil
 }
@@ -1,10 +1,46 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
+// rewriteTransport sends every request to target, keeping path and query.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// withTestServer points the package HTTP client at a test server for the
+// duration of the test.
+func withTestServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	original := httpClient
+	httpClient = &http.Client{Transport: rewriteTransport{target: target}}
+	t.Cleanup(func() {
+		httpClient = original
+		server.Close()
+	})
+}
+
 func TestFetchEstimate_ObjectFormat(t *testing.T) {
 	mockResponse := `{
 		"min": 0.04444,
@@ -54,7 +90,8 @@ func TestFetchEstimate_ObjectFormat(t *testing.T) {
 		t.Errorf("Expected 3 rates, got %d", len(result.Rates.Results))
 	}
 
-	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", result.Rates.TradeValue_fiat)
+	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", Money{Amount: result.Rates.TradeValue_fiat, Currency: "usd"}, "coingecko")
+	ByAmount.Rank(estimates)
 	if len(estimates) != 3 {
 		t.Errorf("Expected 3 estimates after population, got %d", len(estimates))
 	}
@@ -108,7 +145,8 @@ func TestFetchEstimate_Sorting(t *testing.T) {
 		t.Fatalf("Failed to unmarshal: %v", err)
 	}
 
-	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", result.Rates.TradeValue_fiat)
+	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", Money{Amount: result.Rates.TradeValue_fiat, Currency: "usd"}, "coingecko")
+	ByAmount.Rank(estimates)
 
 	if estimates[0].ReceiveAmount != 0.0200 {
 		t.Errorf("Expected highest amount first, got %f", estimates[0].ReceiveAmount)
@@ -152,7 +190,7 @@ func TestFetchEstimate_SingleEstimate(t *testing.T) {
 		t.Fatalf("Failed to unmarshal: %v", err)
 	}
 
-	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", result.Rates.TradeValue_fiat)
+	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", Money{Amount: result.Rates.TradeValue_fiat, Currency: "usd"}, "coingecko")
 	if len(estimates) != 1 {
 		t.Errorf("Expected 1 estimate, got %d", len(estimates))
 	}
@@ -191,8 +229,189 @@ func TestFetchEstimate_EmptyResponse(t *testing.T) {
 		t.Fatalf("Failed to unmarshal: %v", err)
 	}
 
-	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", result.Rates.TradeValue_fiat)
+	estimates := populateEstimates(result.Rates.Results, "btc", "eth", 1.0, "btc", "eth", Money{Amount: result.Rates.TradeValue_fiat, Currency: "usd"}, "coingecko")
 	if len(estimates) != 0 {
 		t.Errorf("Expected 0 estimates, got %d", len(estimates))
 	}
 }
+
+func TestTrackTx_QueriesByID(t *testing.T) {
+	var gotID string
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transaction" {
+			t.Errorf("Expected path /transaction, got %s", r.URL.Path)
+		}
+		gotID = r.URL.Query().Get("id")
+		fmt.Fprint(w, `{"transaction": {"Id": "abc123", "Status": "exchanging", "Provider": "ChangeNow"}}`)
+	})
+
+	tx, err := TrackTxFromAPI(context.Background(), Transaction{Id: "abc123", Provider: "ChangeNow"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if gotID != "abc123" {
+		t.Errorf("Expected lookup by Id 'abc123', got '%s'", gotID)
+	}
+	if tx.Status != StatusExchanging {
+		t.Errorf("Expected status %s, got %s", StatusExchanging, tx.Status)
+	}
+	if tx.Done {
+		t.Error("Expected Done to be false for an exchanging transaction")
+	}
+}
+
+func TestTrackTx_FallsBackToCGID(t *testing.T) {
+	var gotID string
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.URL.Query().Get("id")
+		fmt.Fprint(w, `{"transaction": {"CGID": "cg-42", "Status": "finished"}}`)
+	})
+
+	tx, err := TrackTxFromAPI(context.Background(), Transaction{CGID: "cg-42"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if gotID != "cg-42" {
+		t.Errorf("Expected lookup by CGID 'cg-42', got '%s'", gotID)
+	}
+	if !tx.Done {
+		t.Error("Expected Done to be true for a finished transaction")
+	}
+}
+
+func TestTrackTx_NoIdentifier(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Server should not be called without an identifier")
+	})
+
+	if _, err := TrackTxFromAPI(context.Background(), Transaction{Provider: "ChangeNow"}); err == nil {
+		t.Error("Expected error for transaction without Id or CGID, got nil")
+	}
+}
+
+func TestTrackTx_UnknownStatus(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"transaction": {"Id": "abc123", "Status": "teleporting"}}`)
+	})
+
+	tx, err := TrackTxFromAPI(context.Background(), Transaction{Id: "abc123", Status: StatusWaiting})
+	if err == nil {
+		t.Fatal("Expected error for unknown status, got nil")
+	}
+	if tx.Status != StatusWaiting {
+		t.Errorf("Expected status to stay %s on error, got %s", StatusWaiting, tx.Status)
+	}
+}
+
+func TestTrackTx_ProviderVocabularies(t *testing.T) {
+	providers := map[string][]struct {
+		raw  string
+		want TxStatus
+	}{
+		"ChangeNow": {
+			{"new", StatusWaiting},
+			{"waiting", StatusWaiting},
+			{"confirming", StatusConfirming},
+			{"exchanging", StatusExchanging},
+			{"sending", StatusSending},
+			{"finished", StatusFinished},
+			{"failed", StatusFailed},
+			{"refunded", StatusRefunded},
+			{"verifying", StatusConfirming},
+			{"expired", StatusExpired},
+		},
+		"SimpleSwap": {
+			{"waiting", StatusWaiting},
+			{"confirming", StatusConfirming},
+			{"exchanging", StatusExchanging},
+			{"sending", StatusSending},
+			{"finished", StatusFinished},
+			{"failed", StatusFailed},
+			{"refunded", StatusRefunded},
+			{"expired", StatusExpired},
+		},
+		"FixedFloat": {
+			{"NEW", StatusWaiting},
+			{"PENDING", StatusWaiting},
+			{"EXCHANGE", StatusExchanging},
+			{"WITHDRAW", StatusSending},
+			{"DONE", StatusFinished},
+			{"EXPIRED", StatusExpired},
+			{"EMERGENCY", StatusFailed},
+		},
+		"Exolix": {
+			{"wait", StatusWaiting},
+			{"confirmation", StatusConfirming},
+			{"confirmed", StatusConfirming},
+			{"exchanging", StatusExchanging},
+			{"sending", StatusSending},
+			{"success", StatusFinished},
+			{"overdue", StatusExpired},
+			{"refunded", StatusRefunded},
+		},
+		"Godex": {
+			{"wait", StatusWaiting},
+			{"confirmation", StatusConfirming},
+			{"exchanging", StatusExchanging},
+			{"sending", StatusSending},
+			{"success", StatusFinished},
+			{"overdue", StatusExpired},
+			{"refund", StatusRefunded},
+			{"error", StatusFailed},
+		},
+		"Trocador": {
+			{"new", StatusWaiting},
+			{"waiting", StatusWaiting},
+			{"confirming", StatusConfirming},
+			{"paid partially", StatusConfirming},
+			{"sending", StatusSending},
+			{"finished", StatusFinished},
+			{"failed", StatusFailed},
+			{"expired", StatusExpired},
+			{"halted", StatusFailed},
+			{"refunded", StatusRefunded},
+		},
+	}
+
+	for provider, cases := range providers {
+		for _, tc := range cases {
+			t.Run(provider+"/"+tc.raw, func(t *testing.T) {
+				withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+					response := TransactionResponse{Transaction: Transaction{
+						Id:       "tx-1",
+						Provider: provider,
+						Status:   TxStatus(tc.raw),
+					}}
+					json.NewEncoder(w).Encode(response) //nolint:errcheck
+				})
+
+				tx, err := TrackTxFromAPI(context.Background(), Transaction{Id: "tx-1", Provider: provider})
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				if tx.Status != tc.want {
+					t.Errorf("Expected %q to map to %s, got %s", tc.raw, tc.want, tx.Status)
+				}
+				if tx.Done != tc.want.Terminal() {
+					t.Errorf("Expected Done=%v for %s, got %v", tc.want.Terminal(), tc.want, tx.Done)
+				}
+			})
+		}
+	}
+}
+
+func TestGetTransaction_KeepsUnknownStatus(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"transaction": {"Id": "abc123", "Status": "teleporting"}}`)
+	})
+
+	tx, err := GetTransactionFromAPI(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if tx.Status != "teleporting" {
+		t.Errorf("Expected raw status to be kept, got %s", tx.Status)
+	}
+}
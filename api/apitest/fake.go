@@ -0,0 +1,284 @@
+// Package apitest provides an in-memory api.SwapProvider for tests.
+//
+// A Fake answers from scripted responses instead of the network:
+//
+//	fake := apitest.NewFake()
+//	fake.AddEstimates([]api.Estimate{{ExchangeName: "ChangeNow", ReceiveAmount: 1.5}}, nil)
+//	fake.AdvanceStatuses("tx-1", api.StatusConfirming, api.StatusFinished)
+//
+// Trades created through the fake are remembered, so a test can create a
+// trade and then track it.
+package apitest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+)
+
+// Method names, used with Fail and in Call.
+const (
+	MethodEstimate       = "Estimate"
+	MethodCreateTrade    = "CreateTrade"
+	MethodGetTransaction = "GetTransaction"
+	MethodTrack          = "Track"
+)
+
+// ErrNotFound is returned for transactions the fake does not know.
+var ErrNotFound = errors.New("apitest: transaction not found")
+
+// Call records one request made to the fake.
+type Call struct {
+	Method string
+	// Request is the api.EstimateRequest, api.TradeRequest, ID string or
+	// api.Transaction passed to the method.
+	Request any
+}
+
+type estimateResponse struct {
+	estimates []api.Estimate
+	err       error
+}
+
+// Fake is an in-memory api.SwapProvider. The zero value is not usable; build
+// one with NewFake. A Fake is safe for concurrent use.
+type Fake struct {
+	mu           sync.Mutex
+	latency      time.Duration
+	estimates    []estimateResponse
+	failures     map[string][]error
+	transactions map[string]*api.Transaction
+	statuses     map[string][]api.TxStatus
+	calls        []Call
+	nextID       int
+}
+
+var _ api.SwapProvider = (*Fake)(nil)
+
+// NewFake returns a fake with nothing scripted.
+func NewFake() *Fake {
+	return &Fake{
+		failures:     make(map[string][]error),
+		transactions: make(map[string]*api.Transaction),
+		statuses:     make(map[string][]api.TxStatus),
+	}
+}
+
+// SetLatency delays every call by d. A call whose context ends first returns
+// the context's error.
+func (f *Fake) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// AddEstimates queues a response for Estimate. Responses are returned in the
+// order they were added; the last one is repeated once the queue is drained.
+func (f *Fake) AddEstimates(estimates []api.Estimate, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.estimates = append(f.estimates, estimateResponse{estimates: estimates, err: err})
+}
+
+// AddTransaction stores tx so GetTransaction and Track can find it by its Id
+// or CGID.
+func (f *Fake) AddTransaction(tx api.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(tx)
+}
+
+// AdvanceStatuses queues statuses for the transaction with the given Id or
+// CGID. Each Track call moves it to the next status; once the queue is
+// drained the transaction keeps its last status.
+func (f *Fake) AdvanceStatuses(id string, statuses ...api.TxStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[id] = append(f.statuses[id], statuses...)
+}
+
+// Fail makes the next call to method return err. Several failures for the same
+// method are returned in order.
+func (f *Fake) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], err)
+}
+
+// Calls returns the requests made so far, oldest first.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Estimate returns the next scripted estimates, only the fixed-rate ones if
+// req.Fixed is set. Without a script it returns an error.
+func (f *Fake) Estimate(ctx context.Context, req api.EstimateRequest) ([]api.Estimate, error) {
+	if err := f.begin(ctx, MethodEstimate, req); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.estimates) == 0 {
+		return nil, fmt.Errorf("apitest: no estimates scripted for %s -> %s", req.Coin1, req.Coin2)
+	}
+	resp := f.estimates[0]
+	if len(f.estimates) > 1 {
+		f.estimates = f.estimates[1:]
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+
+	estimates := make([]api.Estimate, 0, len(resp.estimates))
+	for _, est := range resp.estimates {
+		est.Coin1 = cmp.Or(est.Coin1, req.Coin1)
+		est.Coin2 = cmp.Or(est.Coin2, req.Coin2)
+		est.Network1 = cmp.Or(est.Network1, req.Network1)
+		est.Network2 = cmp.Or(est.Network2, req.Network2)
+		if est.SendAmount == 0 {
+			est.SendAmount = req.Amount
+		}
+		est.RateType = api.ParseRateType(string(est.RateType))
+		if req.Fixed && !est.Fixed() {
+			continue
+		}
+		estimates = append(estimates, est)
+	}
+	return estimates, nil
+}
+
+// CreateTrade records a new trade in the waiting state with a generated Id,
+// CGID and deposit address.
+func (f *Fake) CreateTrade(ctx context.Context, req api.TradeRequest) (api.Transaction, error) {
+	if err := f.begin(ctx, MethodCreateTrade, req); err != nil {
+		return api.Transaction{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	tx := api.Transaction{
+		Id:         fmt.Sprintf("fake-%d", f.nextID),
+		CGID:       fmt.Sprintf("cg-fake-%d", f.nextID),
+		Coin1:      req.Coin1,
+		Coin2:      req.Coin2,
+		Network1:   req.Network1,
+		Network2:   req.Network2,
+		SendAmount: req.Amount,
+		Provider:   req.Partner,
+		Address:    fmt.Sprintf("fake-deposit-%d", f.nextID),
+		Status:     api.StatusWaiting,
+		CreatedAt:  time.Now().UTC(),
+
+		RefundAddress: req.RefundAddress,
+		RefundMemo:    req.RefundMemo,
+		ExtraID:       req.ExtraID,
+	}
+	if req.RateID != "" {
+		tx.RateType = api.RateFixed
+	}
+	f.store(tx)
+	return tx, nil
+}
+
+// GetTransaction returns the stored transaction with the given Id or CGID.
+func (f *Fake) GetTransaction(ctx context.Context, id string) (api.Transaction, error) {
+	if err := f.begin(ctx, MethodGetTransaction, id); err != nil {
+		return api.Transaction{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tx, ok := f.transactions[id]
+	if !ok {
+		return api.Transaction{}, fmt.Errorf("failed to get transaction: %w", ErrNotFound)
+	}
+	return *tx, nil
+}
+
+// Track moves the stored transaction to its next scripted status and merges
+// it into t, like api.Client.Track.
+func (f *Fake) Track(ctx context.Context, t api.Transaction) (api.Transaction, error) {
+	if err := f.begin(ctx, MethodTrack, t); err != nil {
+		return t, err
+	}
+
+	id := cmp.Or(t.Id, t.CGID)
+	if id == "" {
+		return t, fmt.Errorf("failed to track transaction: transaction has no Id or CGID")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tx, ok := f.transactions[id]
+	if !ok {
+		return t, fmt.Errorf("failed to track transaction: %w", ErrNotFound)
+	}
+
+	for _, key := range []string{tx.Id, tx.CGID} {
+		if queue := f.statuses[key]; len(queue) > 0 {
+			tx.Status = queue[0]
+			f.statuses[key] = queue[1:]
+			break
+		}
+	}
+	tx.Done = tx.Done || tx.Status.Terminal()
+
+	t.Merge(*tx)
+	t.Status = tx.Status
+	t.Done = tx.Done
+	return t, nil
+}
+
+// begin records the call, waits out the latency and returns any scripted
+// failure.
+func (f *Fake) begin(ctx context.Context, method string, req any) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Request: req})
+	latency := f.latency
+	var err error
+	if queue := f.failures[method]; len(queue) > 0 {
+		err = queue[0]
+		f.failures[method] = queue[1:]
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// store saves tx under its Id and CGID. The caller must hold f.mu.
+func (f *Fake) store(tx api.Transaction) {
+	if tx.Status != "" {
+		if status, err := api.ParseTxStatus(string(tx.Status)); err == nil {
+			tx.Status = status
+		}
+	}
+	stored := &tx
+	for _, key := range []string{tx.Id, tx.CGID} {
+		if key = strings.TrimSpace(key); key != "" {
+			f.transactions[key] = stored
+		}
+	}
+}
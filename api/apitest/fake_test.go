@@ -0,0 +1,145 @@
+package apitest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+)
+
+func TestFake_EstimateScript(t *testing.T) {
+	fake := NewFake()
+	boom := errors.New("boom")
+	fake.AddEstimates(nil, boom)
+	fake.AddEstimates([]api.Estimate{{ExchangeName: "ChangeNow", ReceiveAmount: 1.5}}, nil)
+
+	req := api.EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1, Network1: "btc", Network2: "xmr"}
+
+	if _, err := fake.Estimate(context.Background(), req); !errors.Is(err, boom) {
+		t.Fatalf("Expected scripted error, got %v", err)
+	}
+
+	for range 2 {
+		estimates, err := fake.Estimate(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Estimate failed: %v", err)
+		}
+		if len(estimates) != 1 || estimates[0].ExchangeName != "ChangeNow" {
+			t.Fatalf("Unexpected estimates: %+v", estimates)
+		}
+		if estimates[0].Coin2 != "xmr" || estimates[0].SendAmount != 0.1 {
+			t.Errorf("Expected request fields filled in, got %+v", estimates[0])
+		}
+	}
+
+	if got := len(fake.Calls()); got != 3 {
+		t.Errorf("Expected 3 recorded calls, got %d", got)
+	}
+}
+
+func TestFake_EstimateUnscripted(t *testing.T) {
+	if _, err := NewFake().Estimate(context.Background(), api.EstimateRequest{}); err == nil {
+		t.Fatal("Expected an error without scripted estimates")
+	}
+}
+
+func TestFake_EstimateFixed(t *testing.T) {
+	fake := NewFake()
+	fake.AddEstimates([]api.Estimate{
+		{ExchangeName: "Floating", ReceiveAmount: 1.5},
+		{ExchangeName: "Locked", ReceiveAmount: 1.4, RateType: api.RateFixed, RateID: "r-1"},
+	}, nil)
+
+	estimates, err := fake.Estimate(context.Background(), api.EstimateRequest{Coin1: "btc", Coin2: "xmr", Fixed: true})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if len(estimates) != 1 || estimates[0].ExchangeName != "Locked" {
+		t.Fatalf("Expected only the fixed-rate offer, got %+v", estimates)
+	}
+
+	tx, err := fake.CreateTrade(context.Background(), api.TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1, RateID: "r-1"})
+	if err != nil {
+		t.Fatalf("CreateTrade failed: %v", err)
+	}
+	if tx.RateType != api.RateFixed {
+		t.Errorf("Expected a fixed-rate trade, got %q", tx.RateType)
+	}
+}
+
+func TestFake_CreateAndTrack(t *testing.T) {
+	fake := NewFake()
+	ctx := context.Background()
+
+	tx, err := fake.CreateTrade(ctx, api.TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1, Partner: "ChangeNow"})
+	if err != nil {
+		t.Fatalf("CreateTrade failed: %v", err)
+	}
+	if tx.Id == "" || tx.CGID == "" || tx.Address == "" || tx.Status != api.StatusWaiting {
+		t.Fatalf("Unexpected trade: %+v", tx)
+	}
+
+	fake.AdvanceStatuses(tx.Id, api.StatusExchanging, api.StatusFinished)
+
+	want := []api.TxStatus{api.StatusExchanging, api.StatusFinished, api.StatusFinished}
+	for i, status := range want {
+		got, err := fake.Track(ctx, api.Transaction{CGID: tx.CGID})
+		if err != nil {
+			t.Fatalf("Track %d failed: %v", i, err)
+		}
+		if got.Status != status {
+			t.Errorf("Track %d: expected %s, got %s", i, status, got.Status)
+		}
+		if got.Done != status.Terminal() {
+			t.Errorf("Track %d: expected Done=%v", i, status.Terminal())
+		}
+	}
+
+	got, err := fake.GetTransaction(ctx, tx.Id)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if got.Status != api.StatusFinished {
+		t.Errorf("Expected stored status finished, got %s", got.Status)
+	}
+}
+
+func TestFake_UnknownTransaction(t *testing.T) {
+	_, err := NewFake().GetTransaction(context.Background(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFake_Fail(t *testing.T) {
+	fake := NewFake()
+	fake.AddTransaction(api.Transaction{Id: "tx-1", Status: "wait"})
+	boom := errors.New("boom")
+	fake.Fail(MethodTrack, boom)
+
+	if _, err := fake.Track(context.Background(), api.Transaction{Id: "tx-1"}); !errors.Is(err, boom) {
+		t.Fatalf("Expected scripted failure, got %v", err)
+	}
+	tx, err := fake.Track(context.Background(), api.Transaction{Id: "tx-1"})
+	if err != nil {
+		t.Fatalf("Expected the failure to be used once, got %v", err)
+	}
+	if tx.Status != api.StatusWaiting {
+		t.Errorf("Expected the provider status to be normalized, got %s", tx.Status)
+	}
+}
+
+func TestFake_Latency(t *testing.T) {
+	fake := NewFake()
+	fake.SetLatency(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := fake.GetTransaction(ctx, "tx-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline, got %v", err)
+	}
+}
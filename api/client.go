@@ -1,14 +1,22 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 )
 
+const defaultUserAgent = "cyphergoat-cli"
+
 var httpClient = &http.Client{
 	Timeout: 30 * time.Second,
 }
@@ -17,35 +25,444 @@ func GetHTTPClient() *http.Client {
 	return httpClient
 }
 
+// Client talks to the CypherGoat API. Build one with NewClient; the zero
+// value is not usable. A Client is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	userAgent  string
+	logger     *slog.Logger
+	prices     PriceSource
+	retry      RetryPolicy
+	ranker     Ranker
+	currency   string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at another API host, such as a staging
+// server or a local mock. The default is https://api.cyphergoat.com.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithAPIKey sets the key sent as a bearer token.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithLogger sets the logger requests are reported to at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithPriceService sets the service used to value estimates. Without it the
+// client builds one that shares its HTTP transport.
+func WithPriceService(prices *PriceService) Option {
+	return func(c *Client) {
+		if prices != nil {
+			c.prices = prices
+		}
+	}
+}
+
+// WithPriceSource values estimates with another source than CoinGecko, such
+// as a PriceChain that falls back to other sources.
+func WithPriceSource(source PriceSource) Option {
+	return func(c *Client) {
+		c.prices = source
+	}
+}
+
+// WithRetryPolicy sets how requests are retried after transient failures.
+// Trade creation is only retried when the request carries an idempotency
+// key.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithRanker sets how estimates are ordered. By default forward quotes are
+// ranked ByAmount and reverse quotes ByDeposit.
+func WithRanker(ranker Ranker) Option {
+	return func(c *Client) {
+		c.ranker = ranker
+	}
+}
+
+// WithCurrency sets the currency estimates are valued in: a fiat currency
+// such as "eur", or "btc". The default is DefaultCurrency.
+func WithCurrency(currency string) Option {
+	return func(c *Client) {
+		c.currency = strings.ToLower(currency)
+	}
+}
+
+// NewClient returns a client with the given options applied over the
+// defaults: the public API host, no API key, a 30 second timeout,
+// DefaultRetryPolicy and a CoinGecko price service.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    "https://" + URL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  defaultUserAgent,
+		logger:     slog.New(slog.DiscardHandler),
+		retry:      DefaultRetryPolicy(),
+		currency:   DefaultCurrency,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.prices == nil {
+		// Prices go through the same transport as API requests, so a proxy
+		// set on the HTTP client covers both.
+		prices := NewPriceService()
+		prices.SetHTTPClient(&http.Client{
+			Timeout:   priceTimeout,
+			Transport: c.httpClient.Transport,
+		})
+		prices.SetRetryPolicy(c.retry)
+		c.prices = prices
+	}
+	return c
+}
+
+// defaultClient builds a client from the package-level API key and HTTP
+// client for the legacy package functions.
+func defaultClient() *Client {
+	return NewClient(WithAPIKey(API_KEY), WithHTTPClient(httpClient))
+}
+
+// APIKey returns the key the client authenticates with.
+func (c *Client) APIKey() string {
+	return c.apiKey
+}
+
+// Estimate quotes a swap with every partner exchange, best offer first as
+// ranked by the client's Ranker. With req.Fixed set, only fixed-rate offers
+// are returned. With req.ReceiveAmount set it quotes in reverse, as described
+// at EstimateRequest, cheapest first unless another Ranker is set.
+func (c *Client) Estimate(ctx context.Context, req EstimateRequest) ([]Estimate, error) {
+	if req.ReceiveAmount > 0 {
+		return c.reverseEstimate(ctx, req)
+	}
+
+	results, err := c.fetchEstimates(ctx, req, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	price, source := c.price(ctx, req.Coin2)
+	estimates := populateEstimates(results, req.Coin1, req.Coin2, req.Amount, req.Network1, req.Network2, price, source)
+	c.rankerFor(ByAmount).Rank(estimates)
+	return filterFixed(estimates, req.Fixed), nil
+}
+
+// rankerFor returns the client's Ranker, or fallback if none was set.
+func (c *Client) rankerFor(fallback Ranker) Ranker {
+	if c.ranker != nil {
+		return c.ranker
+	}
+	return fallback
+}
+
+// fetchEstimates asks the API for estimates of swapping amount of req.Coin1
+// and returns them as sent.
+func (c *Client) fetchEstimates(ctx context.Context, req EstimateRequest, amount float64) ([]Estimate, error) {
+	params := url.Values{}
+	params.Set("coin1", req.Coin1)
+	params.Set("coin2", req.Coin2)
+	params.Set("amount", fmt.Sprintf("%f", amount))
+	params.Set("network1", req.Network1)
+	params.Set("network2", req.Network2)
+	if req.Best {
+		params.Set("best", "true")
+	}
+	if req.Fixed {
+		params.Set("fixed", "true")
+	}
+	if req.ReceiveAmount > 0 {
+		params.Set("receive_amount", fmt.Sprintf("%f", req.ReceiveAmount))
+	}
+
+	data, err := c.get(ctx, "/estimate", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch estimate: %w", err)
+	}
+
+	type RatesWrapper struct {
+		Results         []Estimate `json:"Results"`
+		Min             float64    `json:"Min"`
+		TradeValue_fiat float64    `json:"TradeValue_fiat"`
+		TradeValue_btc  float64    `json:"TradeValue_btc"`
+	}
+
+	type ApiResponse struct {
+		Min   float64      `json:"min"`
+		Rates RatesWrapper `json:"rates"`
+	}
+
+	var result ApiResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal estimate response: %w", err)
+	}
+
+	// Exchanges that report no minimum of their own are held to the
+	// overall one.
+	if overall := cmp.Or(result.Min, result.Rates.Min); overall > 0 {
+		for i := range result.Rates.Results {
+			if result.Rates.Results[i].MinAmount == 0 {
+				result.Rates.Results[i].MinAmount = overall
+			}
+		}
+	}
+	return result.Rates.Results, nil
+}
+
+// price returns the price of one coin in the client's currency and the
+// source that gave it. The source is empty, and the amount 0, if the price is
+// not known.
+func (c *Client) price(ctx context.Context, coin string) (Money, string) {
+	quotes, err := c.prices.Prices(ctx, []string{coin}, c.currency)
+	quote, ok := quotes[coin]
+	if err != nil || !ok {
+		c.logger.Debug("price lookup failed", "coin", coin, "currency", c.currency, "error", err)
+		return Money{Currency: c.currency}, ""
+	}
+	return Money{Amount: quote.Price, Currency: c.currency}, quote.Source
+}
+
+// filterFixed drops floating-rate offers when fixed is set. Providers that
+// only quote floating rates may still answer a request for fixed ones.
+func filterFixed(estimates []Estimate, fixed bool) []Estimate {
+	if !fixed {
+		return estimates
+	}
+	return slices.DeleteFunc(estimates, func(e Estimate) bool { return !e.Fixed() })
+}
+
+// CreateTrade creates a trade with the partner exchange named in req.
+func (c *Client) CreateTrade(ctx context.Context, req TradeRequest) (Transaction, error) {
+	params := url.Values{}
+	params.Set("coin1", req.Coin1)
+	params.Set("coin2", req.Coin2)
+	params.Set("amount", fmt.Sprintf("%f", req.Amount))
+	params.Set("partner", req.Partner)
+	params.Set("address", req.Address)
+	params.Set("network1", req.Network1)
+	params.Set("network2", req.Network2)
+	if req.RefundAddress != "" {
+		params.Set("refund_address", req.RefundAddress)
+	}
+	if req.RefundMemo != "" {
+		params.Set("refund_memo", req.RefundMemo)
+	}
+	if req.ExtraID != "" {
+		params.Set("extra_id", req.ExtraID)
+	}
+	if req.RateID != "" {
+		params.Set("rate_id", req.RateID)
+	}
+
+	// Creating a trade twice would leave the user with two deposit addresses,
+	// so it is only retried when the API can tell repeats apart.
+	data, err := c.do(ctx, request{
+		path:           "/swap",
+		params:         params,
+		idempotencyKey: req.IdempotencyKey,
+		retry:          req.IdempotencyKey != "",
+	})
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to create trade: %w", err)
+	}
+
+	var result TransactionResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Transaction{}, fmt.Errorf("failed to unmarshal trade response: %w", err)
+	}
+
+	transaction := result.Transaction
+	return transaction, nil
+}
+
+// Track refreshes t from the API. The transaction is looked up by its Id, or
+// by its CGID when the Id is not known. The provider's status is normalized
+// onto TxStatus and Done is set once the status is terminal. An unknown
+// status is returned as an error.
+func (c *Client) Track(ctx context.Context, t Transaction) (Transaction, error) {
+	id := t.Id
+	if id == "" {
+		id = t.CGID
+	}
+	if id == "" {
+		return t, fmt.Errorf("failed to track transaction: transaction has no Id or CGID")
+	}
+
+	latest, err := c.fetchTransaction(ctx, id)
+	if err != nil {
+		return t, fmt.Errorf("failed to track transaction: %w", err)
+	}
+
+	status, err := ParseTxStatus(string(latest.Status))
+	if err != nil {
+		return t, fmt.Errorf("failed to track transaction: %w", err)
+	}
+
+	t.Merge(latest)
+	t.Status = status
+	t.Done = latest.Done || status.Terminal()
+
+	return t, nil
+}
+
+// GetTransaction fetches a transaction by its Id or CGID. Known provider
+// statuses are normalized onto TxStatus; unknown ones are kept as reported.
+func (c *Client) GetTransaction(ctx context.Context, id string) (Transaction, error) {
+	transaction, err := c.fetchTransaction(ctx, id)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	if status, err := ParseTxStatus(string(transaction.Status)); err == nil {
+		transaction.Status = status
+		transaction.Done = transaction.Done || status.Terminal()
+	}
+
+	return transaction, nil
+}
+
+func (c *Client) fetchTransaction(ctx context.Context, id string) (Transaction, error) {
+	params := url.Values{}
+	params.Set("id", id)
+
+	data, err := c.get(ctx, "/transaction", params)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	var result TransactionResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Transaction{}, fmt.Errorf("failed to unmarshal transaction response: %w", err)
+	}
+
+	return result.Transaction, nil
+}
+
+// request describes one API call.
+type request struct {
+	path   string
+	params url.Values
+	// idempotencyKey is sent as the Idempotency-Key header.
+	idempotencyKey string
+	// retry allows retrying after transient failures. Only set it for
+	// requests that are safe to repeat.
+	retry bool
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.do(ctx, request{path: path, params: params, retry: true})
+}
+
+// SendRequestWithContext performs an authenticated GET using the
+// package-level API key and HTTP client. Transient failures are retried.
 func SendRequestWithContext(ctx context.Context, url string) ([]byte, error) {
+	c := defaultClient()
+	return c.sendWithRetry(ctx, url, "", c.retry)
+}
+
+func (c *Client) do(ctx context.Context, r request) ([]byte, error) {
+	policy := c.retry
+	if !r.retry {
+		policy.Retries = 0
+	}
+	return c.sendWithRetry(ctx, c.baseURL+r.path+"?"+r.params.Encode(), r.idempotencyKey, policy)
+}
+
+func (c *Client) sendWithRetry(ctx context.Context, url, idempotencyKey string, policy RetryPolicy) ([]byte, error) {
+	var (
+		data    []byte
+		attempt int
+	)
+	err := policy.do(ctx, func() (retryAttempt, error) {
+		attempt++
+		var (
+			next retryAttempt
+			err  error
+		)
+		data, next, err = c.send(ctx, url, idempotencyKey)
+		if err != nil && next.retry && attempt <= policy.Retries {
+			c.logger.Debug("api request failed, retrying", "attempt", attempt, "error", err)
+		}
+		return next, err
+	})
+	return data, err
+}
+
+// send makes a single GET request. On failure it also reports whether the
+// failure is transient.
+func (c *Client) send(ctx context.Context, url, idempotencyKey string) ([]byte, retryAttempt, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, retryAttempt{}, err
 	}
 
-	if API_KEY != "" {
-		req.Header.Add("Authorization", "Bearer "+API_KEY)
+	if c.apiKey != "" {
+		req.Header.Add("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
 	}
 
-	resp, err := httpClient.Do(req)
+	c.logger.Debug("api request", "method", req.Method, "path", req.URL.Path)
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		// DNS failures, refused connections, resets and client timeouts are
+		// transient unless the caller's context ended.
+		return nil, retryAttempt{retry: ctx.Err() == nil}, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	c.logger.Debug("api response", "path", req.URL.Path, "status", resp.StatusCode)
+
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-
-	var responseMap map[string]any
-	if err := json.Unmarshal(data, &responseMap); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
+		return nil, retryAttempt{retry: ctx.Err() == nil}, err
 	}
 
-	if errStr, ok := responseMap["error"].(string); ok {
-		return nil, fmt.Errorf("API error: %s", errStr)
+	if err := parseAPIError(resp, data); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return nil, retryResponse(apiErr.StatusCode, apiErr.RetryAfter), err
+		}
+		return nil, retryAttempt{}, err
 	}
 
-	return data, nil
+	return data, retryAttempt{}, nil
 }
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_UsesOptions(t *testing.T) {
+	var gotAuth, gotAgent, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAgent = r.Header.Get("User-Agent")
+		gotPath = r.URL.Path
+		fmt.Fprint(w, `{"transaction": {"id": "abc", "status": "finished"}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL+"/"),
+		WithAPIKey("secret"),
+		WithHTTPClient(server.Client()),
+		WithUserAgent("test-agent/1.0"),
+	)
+
+	tx, err := client.GetTransaction(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+
+	if gotPath != "/transaction" {
+		t.Errorf("Expected path /transaction, got %s", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected bearer auth with the client's key, got %q", gotAuth)
+	}
+	if gotAgent != "test-agent/1.0" {
+		t.Errorf("Expected user agent test-agent/1.0, got %q", gotAgent)
+	}
+	if tx.Status != StatusFinished {
+		t.Errorf("Expected status %s, got %s", StatusFinished, tx.Status)
+	}
+}
+
+func TestClient_IndependentKeys(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"transaction": {"id": "abc", "status": "waiting"}}`)
+	}))
+	defer server.Close()
+
+	first := NewClient(WithBaseURL(server.URL), WithAPIKey("one"))
+	second := NewClient(WithBaseURL(server.URL), WithAPIKey("two"))
+
+	for _, c := range []*Client{first, second} {
+		if _, err := c.GetTransaction(context.Background(), "abc"); err != nil {
+			t.Fatalf("GetTransaction failed: %v", err)
+		}
+	}
+
+	if len(keys) != 2 || keys[0] != "Bearer one" || keys[1] != "Bearer two" {
+		t.Errorf("Expected each client to send its own key, got %v", keys)
+	}
+}
+
+func TestClient_Estimate(t *testing.T) {
+	prices := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"monero": {"usd": 150.0}}`)
+	}))
+	defer prices.Close()
+
+	var gotQuery map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/estimate" {
+			t.Errorf("Expected path /estimate, got %s", r.URL.Path)
+		}
+		gotQuery = map[string]string{}
+		for k := range r.URL.Query() {
+			gotQuery[k] = r.URL.Query().Get(k)
+		}
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [
+			{"Exchange": "Low", "Amount": 1.5},
+			{"Exchange": "High", "Amount": 2.0}
+		]}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPriceService(NewPriceServiceWithURL(prices.URL)),
+	)
+
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{
+		Coin1:    "btc",
+		Coin2:    "xmr",
+		Amount:   0.1,
+		Network1: "btc",
+		Network2: "xmr",
+	})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	if gotQuery["coin1"] != "btc" || gotQuery["coin2"] != "xmr" || gotQuery["network2"] != "xmr" {
+		t.Errorf("Unexpected query parameters: %v", gotQuery)
+	}
+	if _, ok := gotQuery["best"]; ok {
+		t.Errorf("Did not expect best parameter when Best is false")
+	}
+	if len(estimates) != 2 || estimates[0].ExchangeName != "High" {
+		t.Fatalf("Expected estimates sorted best first, got %+v", estimates)
+	}
+	if estimates[0].TradeValue != (Money{Amount: 300.0, Currency: "usd"}) {
+		t.Errorf("Expected trade value 300.00 USD from the injected price service, got %v", estimates[0].TradeValue)
+	}
+}
+
+func TestClient_EstimateCurrency(t *testing.T) {
+	prices := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"monero": {"usd": 150.0, "chf": 120.0}}`)
+	}))
+	defer prices.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [{"Exchange": "Only", "Amount": 2.0}]}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPriceService(NewPriceServiceWithURL(prices.URL)),
+		WithCurrency("CHF"),
+	)
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if len(estimates) != 1 || estimates[0].TradeValue != (Money{Amount: 240.0, Currency: "chf"}) {
+		t.Errorf("Expected a value of 240.00 CHF, got %+v", estimates)
+	}
+}
+
+func TestClient_EstimatePriceSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [{"Exchange": "Only", "Amount": 2.0}]}}`)
+	}))
+	defer server.Close()
+
+	chain := NewPriceChain(failingSource{}, NewStaticPriceSource(map[string]map[string]float64{"xmr": {"usd": 150}}))
+	client := NewClient(WithBaseURL(server.URL), WithPriceSource(chain))
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if len(estimates) != 1 || estimates[0].TradeValue.Amount != 300 || estimates[0].PriceSource != "file" {
+		t.Errorf("Expected a value of 300 priced by the fallback, got %+v", estimates)
+	}
+
+	client = NewClient(WithBaseURL(server.URL), WithPriceSource(failingSource{}))
+	estimates, err = client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if len(estimates) != 1 || estimates[0].Valued() {
+		t.Errorf("Expected an estimate without a value when no source knows the price, got %+v", estimates)
+	}
+}
+
+func TestClient_EstimateMinimum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"min": 0.01, "rates": {"Results": [
+			{"Exchange": "Own", "Amount": 0, "MinAmount": 0.05, "MaxAmount": 2},
+			{"Exchange": "Overall", "Amount": 1.5}
+		]}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithPriceService(NewPriceServiceWithURL(server.URL)))
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.02})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	byName := map[string]Estimate{}
+	for _, est := range estimates {
+		byName[est.ExchangeName] = est
+	}
+	if own := byName["Own"]; own.MinAmount != 0.05 || own.MaxAmount != 2 || own.WithinLimits() {
+		t.Errorf("Expected the exchange's own limits to be kept and the amount rejected, got %+v", own)
+	}
+	if overall := byName["Overall"]; overall.MinAmount != 0.01 || !overall.WithinLimits() {
+		t.Errorf("Expected the overall minimum to apply, got %+v", overall)
+	}
+}
+
+func TestClient_EstimateFixed(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/estimate" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [
+			{"Exchange": "Floating", "Amount": 2.0},
+			{"Exchange": "Locked", "Amount": 1.9, "RateType": "fixed", "RateId": "r-1", "ExpiresAt": "2030-01-01T00:00:00Z"}
+		]}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPriceService(NewPriceServiceWithURL(server.URL)),
+	)
+
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1, Fixed: true})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if query.Get("fixed") != "true" {
+		t.Errorf("Expected fixed=true to be sent, got %q", query.Get("fixed"))
+	}
+	if len(estimates) != 1 || estimates[0].ExchangeName != "Locked" {
+		t.Fatalf("Expected only the fixed-rate offer, got %+v", estimates)
+	}
+	est := estimates[0]
+	if !est.Fixed() || est.RateID != "r-1" || est.ExpiresAt.Year() != 2030 {
+		t.Errorf("Expected the rate ID and expiry to be decoded, got %+v", est)
+	}
+
+	estimates, err = client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if len(estimates) != 2 || estimates[0].RateType != RateFloating {
+		t.Errorf("Expected both offers, missing rate types as floating, got %+v", estimates)
+	}
+}
+
+func TestClient_CreateTradeRateID(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"error": {"code": "rate_expired", "message": "rate is no longer available"}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	_, err := client.CreateTrade(context.Background(), TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 1, RateID: "r-1"})
+	if query.Get("rate_id") != "r-1" {
+		t.Errorf("Expected rate_id r-1, got %q", query.Get("rate_id"))
+	}
+	if !errors.Is(err, ErrQuoteExpired) {
+		t.Errorf("Expected ErrQuoteExpired, got %v", err)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error": "invalid API key"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	_, err := client.CreateTrade(context.Background(), TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 1})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if err.Error() != "failed to create trade: API error: invalid API key" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestClient_CreateTradeRefundAndMemo(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"transaction": {"Id": "abc", "ExtraId": "12345", "RefundAddress": "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	tx, err := client.CreateTrade(context.Background(), TradeRequest{
+		Coin1:         "btc",
+		Coin2:         "xrp",
+		Amount:        1,
+		Address:       "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh",
+		RefundAddress: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		ExtraID:       "12345",
+	})
+	if err != nil {
+		t.Fatalf("CreateTrade failed: %v", err)
+	}
+
+	if query.Get("refund_address") != "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" {
+		t.Errorf("Expected refund_address to be sent, got %q", query.Get("refund_address"))
+	}
+	if query.Get("extra_id") != "12345" {
+		t.Errorf("Expected extra_id 12345, got %q", query.Get("extra_id"))
+	}
+	if query.Has("refund_memo") {
+		t.Error("Expected an empty refund_memo to be left out")
+	}
+	if tx.ExtraID != "12345" || tx.RefundAddress != "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" {
+		t.Errorf("Expected refund and memo fields in the response, got %+v", tx)
+	}
+}
+
+// countingTransport counts the requests sent through it per host.
+type countingTransport struct {
+	mu    sync.Mutex
+	hosts map[string]int
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.mu.Lock()
+	ct.hosts[req.URL.Host]++
+	ct.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_PricesShareTransport(t *testing.T) {
+	prices := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"monero": {"usd": 150.0}}`)
+	}))
+	defer prices.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [{"Exchange": "High", "Amount": 2.0}]}}`)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{hosts: make(map[string]int)}
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	// Point the client's own price service at the test server; its HTTP
+	// client must still be the one sharing the transport.
+	client.prices.(*PriceService).baseURL = prices.URL
+
+	if _, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 1}); err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	for _, u := range []string{server.URL, prices.URL} {
+		host := strings.TrimPrefix(u, "http://")
+		if transport.hosts[host] != 1 {
+			t.Errorf("Expected one request to %s through the shared transport, got %d", host, transport.hosts[host])
+		}
+	}
+}
+
+func TestClient_EstimateRanker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/estimate" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprint(w, `{"min": 0, "rates": {"Results": [
+			{"Exchange": "Private", "Amount": 0.01, "KYCScore": 1},
+			{"Exchange": "Best", "Amount": 0.02, "KYCScore": 4}
+		]}}`)
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		ranker Ranker
+		want   string
+	}{
+		{nil, "Best"},
+		{ByKYC, "Private"},
+	} {
+		opts := []Option{WithBaseURL(server.URL), WithPriceService(NewPriceServiceWithURL(server.URL))}
+		if tc.ranker != nil {
+			opts = append(opts, WithRanker(tc.ranker))
+		}
+		estimates, err := NewClient(opts...).Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", Amount: 0.1})
+		if err != nil {
+			t.Fatalf("Estimate failed: %v", err)
+		}
+		if len(estimates) != 2 || estimates[0].ExchangeName != tc.want {
+			t.Errorf("Expected %s first, got %+v", tc.want, estimates)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
+)
+
+const coinPaprikaURL = "https://api.coinpaprika.com/v1/tickers"
+
+// CoinPaprikaSource prices coins with CoinPaprika's free ticker API, one
+// request per coin.
+type CoinPaprikaSource struct {
+	baseURL string
+	client  *http.Client
+	retry   RetryPolicy
+}
+
+// NewCoinPaprikaSource returns a CoinPaprika source that makes requests with
+// client, or with a default client if it is nil.
+func NewCoinPaprikaSource(client *http.Client) *CoinPaprikaSource {
+	if client == nil {
+		client = &http.Client{Timeout: priceTimeout}
+	}
+	return &CoinPaprikaSource{baseURL: coinPaprikaURL, client: client, retry: DefaultRetryPolicy()}
+}
+
+// SetRetryPolicy sets how lookups are retried after transient failures. It
+// must be called before the source is used.
+func (s *CoinPaprikaSource) SetRetryPolicy(policy RetryPolicy) {
+	s.retry = policy
+}
+
+// Name implements PriceSource.
+func (s *CoinPaprikaSource) Name() string {
+	return "coinpaprika"
+}
+
+// Prices implements PriceSource.
+func (s *CoinPaprikaSource) Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	return pricesEach(ctx, s.Name(), coins, currency, s.price)
+}
+
+func (s *CoinPaprikaSource) price(ctx context.Context, coin, currency string) (float64, error) {
+	id := coins.Default().CoinPaprikaID(coin)
+	if id == "" {
+		return 0, fmt.Errorf("unknown coin: %s", coin)
+	}
+	quote := strings.ToUpper(currency)
+
+	var ticker struct {
+		Quotes map[string]struct {
+			Price float64 `json:"price"`
+		} `json:"quotes"`
+	}
+	if err := getPriceJSON(ctx, s.client, s.retry, fmt.Sprintf("%s/%s?quotes=%s", s.baseURL, id, quote), &ticker); err != nil {
+		return 0, err
+	}
+	q, ok := ticker.Quotes[quote]
+	if !ok || q.Price <= 0 {
+		return 0, fmt.Errorf("price not found for %s in %s", id, currency)
+	}
+	return q.Price, nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors for the failures callers usually handle differently. An
+// *APIError unwraps to one of them when its cause is known, so
+//
+//	errors.Is(err, api.ErrUnauthorized)
+//
+// works on any error returned by a Client.
+var (
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrPairUnsupported    = errors.New("pair not supported")
+	ErrAmountBelowMinimum = errors.New("amount below minimum")
+	ErrInvalidAddress     = errors.New("invalid address")
+	ErrUnavailable        = errors.New("service unavailable")
+	ErrQuoteExpired       = errors.New("quote expired")
+)
+
+// APIError is an error reported by the CypherGoat API, either as an HTTP error
+// status or as an "error" field in the response body.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the machine-readable error code, if the API sent one.
+	Code string
+	// Message is the human-readable error message.
+	Message string
+	// RequestID identifies the request in the API's logs, if known.
+	RequestID string
+	// RetryAfter is how long the API asked clients to wait before retrying,
+	// or zero if it did not say.
+	RetryAfter time.Duration
+
+	kind error
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.ToLower(http.StatusText(e.StatusCode))
+	}
+
+	var details []string
+	if e.StatusCode >= 400 {
+		details = append(details, fmt.Sprintf("HTTP %d", e.StatusCode))
+	}
+	if e.RequestID != "" {
+		details = append(details, "request ID "+e.RequestID)
+	}
+	if len(details) == 0 {
+		return "API error: " + msg
+	}
+	return fmt.Sprintf("API error: %s (%s)", msg, strings.Join(details, ", "))
+}
+
+// Unwrap returns the sentinel error matching the cause, or nil.
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// errorCodes maps the API's error codes onto sentinel errors.
+var errorCodes = map[string]error{
+	"unauthorized":         ErrUnauthorized,
+	"invalid_api_key":      ErrUnauthorized,
+	"rate_limited":         ErrRateLimited,
+	"too_many_requests":    ErrRateLimited,
+	"pair_unsupported":     ErrPairUnsupported,
+	"unsupported_pair":     ErrPairUnsupported,
+	"amount_below_minimum": ErrAmountBelowMinimum,
+	"amount_too_low":       ErrAmountBelowMinimum,
+	"invalid_address":      ErrInvalidAddress,
+	"quote_expired":        ErrQuoteExpired,
+	"rate_expired":         ErrQuoteExpired,
+}
+
+// errorMessages matches error messages from APIs that send no code. Entries are
+// checked in order against the lower-cased message.
+var errorMessages = []struct {
+	substr string
+	kind   error
+}{
+	{"api key", ErrUnauthorized},
+	{"unauthorized", ErrUnauthorized},
+	{"rate limit", ErrRateLimited},
+	{"too many requests", ErrRateLimited},
+	{"address", ErrInvalidAddress},
+	{"minimum", ErrAmountBelowMinimum},
+	{"too low", ErrAmountBelowMinimum},
+	{"too small", ErrAmountBelowMinimum},
+	{"expired", ErrQuoteExpired},
+	{"pair", ErrPairUnsupported},
+	{"not supported", ErrPairUnsupported},
+	{"unsupported", ErrPairUnsupported},
+}
+
+// classify picks the sentinel error for a code, message and HTTP status.
+func classify(status int, code, message string) error {
+	if kind, ok := errorCodes[strings.ToLower(code)]; ok {
+		return kind
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500:
+		return ErrUnavailable
+	}
+
+	lower := strings.ToLower(message)
+	for _, m := range errorMessages {
+		if strings.Contains(lower, m.substr) {
+			return m.kind
+		}
+	}
+	return nil
+}
+
+// parseAPIError returns the error described by a response, or nil if the
+// response is a success. data is the response body.
+func parseAPIError(resp *http.Response, data []byte) error {
+	var body struct {
+		Error     json.RawMessage `json:"error"`
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"request_id"`
+	}
+	isJSON := json.Valid(data)
+	if isJSON {
+		// Success bodies need not be objects; only error fields matter here.
+		_ = json.Unmarshal(data, &body)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       body.Code,
+		RequestID:  cmp.Or(resp.Header.Get("X-Request-Id"), body.RequestID),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	if isJSON && len(body.Error) > 0 && string(body.Error) != "null" {
+		// "error" is either a message or an object with code and message.
+		var detail struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body.Error, &apiErr.Message); err != nil {
+			if err := json.Unmarshal(body.Error, &detail); err == nil {
+				apiErr.Code = cmp.Or(detail.Code, apiErr.Code)
+				apiErr.Message = detail.Message
+			}
+		}
+	} else if resp.StatusCode < 400 {
+		if !isJSON {
+			return fmt.Errorf("failed to parse API response: %w", json.Unmarshal(data, new(any)))
+		}
+		return nil
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = body.Message
+	}
+	if apiErr.Message == "" && !isJSON {
+		// Plain-text errors are passed through; HTML error pages are not.
+		text := strings.TrimSpace(string(data))
+		if text != "" && len(text) <= 200 && !strings.HasPrefix(text, "<") {
+			apiErr.Message = text
+		}
+	}
+
+	apiErr.kind = classify(resp.StatusCode, apiErr.Code, apiErr.Message)
+	return apiErr
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIError_Responses(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    map[string]string
+		body      string
+		wantKind  error
+		wantMsg   string
+		wantCode  string
+		wantReqID string
+	}{
+		{
+			name:     "error string in 200 body",
+			status:   http.StatusOK,
+			body:     `{"error": "Invalid API key"}`,
+			wantKind: ErrUnauthorized,
+			wantMsg:  "Invalid API key",
+		},
+		{
+			name:     "error object with code",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"code": "amount_below_minimum", "message": "Amount must be at least 0.01"}}`,
+			wantKind: ErrAmountBelowMinimum,
+			wantMsg:  "Amount must be at least 0.01",
+			wantCode: "amount_below_minimum",
+		},
+		{
+			name:      "top-level code and request ID",
+			status:    http.StatusBadRequest,
+			body:      `{"error": "bad request", "code": "invalid_address", "request_id": "req-1"}`,
+			wantKind:  ErrInvalidAddress,
+			wantMsg:   "bad request",
+			wantCode:  "invalid_address",
+			wantReqID: "req-1",
+		},
+		{
+			name:     "unsupported pair message",
+			status:   http.StatusOK,
+			body:     `{"error": "Pair not supported"}`,
+			wantKind: ErrPairUnsupported,
+			wantMsg:  "Pair not supported",
+		},
+		{
+			name:      "HTML gateway error",
+			status:    http.StatusBadGateway,
+			header:    map[string]string{"X-Request-Id": "req-2"},
+			body:      `<html><body><h1>502 Bad Gateway</h1></body></html>`,
+			wantKind:  ErrUnavailable,
+			wantReqID: "req-2",
+		},
+		{
+			name:     "plain text rate limit",
+			status:   http.StatusTooManyRequests,
+			body:     "slow down",
+			wantKind: ErrRateLimited,
+			wantMsg:  "slow down",
+		},
+		{
+			name:     "status only",
+			status:   http.StatusUnauthorized,
+			body:     `{}`,
+			wantKind: ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{}))
+			_, err := client.GetTransaction(context.Background(), "abc")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *APIError, got %T: %v", err, err)
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("Expected errors.Is(err, %v), got %v", tt.wantKind, err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, apiErr.StatusCode)
+			}
+			if apiErr.Message != tt.wantMsg {
+				t.Errorf("Expected message %q, got %q", tt.wantMsg, apiErr.Message)
+			}
+			if apiErr.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, apiErr.Code)
+			}
+			if apiErr.RequestID != tt.wantReqID {
+				t.Errorf("Expected request ID %q, got %q", tt.wantReqID, apiErr.RequestID)
+			}
+		})
+	}
+}
+
+func TestAPIError_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{})).GetTransaction(context.Background(), "abc")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError, got %v", err)
+	}
+	if apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("Expected RetryAfter 7s, got %s", apiErr.RetryAfter)
+	}
+}
+
+func TestAPIError_Message(t *testing.T) {
+	err := &APIError{StatusCode: http.StatusBadGateway, RequestID: "req-1"}
+	want := "API error: bad gateway (HTTP 502, request ID req-1)"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestSend_NonJSONSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{})).GetTransaction(context.Background(), "abc")
+	if err == nil {
+		t.Fatal("Expected a parse error")
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("Did not expect an *APIError for a successful response, got %v", err)
+	}
+}
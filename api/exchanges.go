@@ -0,0 +1,23 @@
+package api
+
+import (
+	"slices"
+	"strings"
+)
+
+// FilterExchanges drops estimates from exchanges not in only, when only is
+// not empty, and from exchanges in exclude. Names match case-insensitively.
+// Like slices.DeleteFunc, it filters in place.
+func FilterExchanges(estimates []Estimate, only, exclude []string) []Estimate {
+	if len(only) == 0 && len(exclude) == 0 {
+		return estimates
+	}
+	listed := func(names []string, e Estimate) bool {
+		return slices.ContainsFunc(names, func(name string) bool {
+			return strings.EqualFold(strings.TrimSpace(name), e.ExchangeName)
+		})
+	}
+	return slices.DeleteFunc(estimates, func(e Estimate) bool {
+		return (len(only) > 0 && !listed(only, e)) || listed(exclude, e)
+	})
+}
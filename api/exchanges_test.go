@@ -0,0 +1,34 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterExchanges(t *testing.T) {
+	newEstimates := func() []Estimate {
+		return []Estimate{{ExchangeName: "ChangeNow"}, {ExchangeName: "FixedFloat"}, {ExchangeName: "Exolix"}}
+	}
+
+	testCases := []struct {
+		name    string
+		only    []string
+		exclude []string
+		want    []string
+	}{
+		{"no lists", nil, nil, []string{"ChangeNow", "FixedFloat", "Exolix"}},
+		{"only", []string{"exolix", "changenow"}, nil, []string{"ChangeNow", "Exolix"}},
+		{"exclude", nil, []string{"FIXEDFLOAT"}, []string{"ChangeNow", "Exolix"}},
+		{"exclude wins", []string{"ChangeNow", "Exolix"}, []string{"exolix"}, []string{"ChangeNow"}},
+		{"unknown only", []string{"Nowhere"}, nil, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := exchangeNames(FilterExchanges(newEstimates(), tc.only, tc.exclude))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const krakenURL = "https://api.kraken.com/0/public/Ticker"
+
+// krakenAssets maps tickers to Kraken asset codes where they differ.
+var krakenAssets = map[string]string{
+	"btc":  "XBT",
+	"doge": "XDG",
+}
+
+// KrakenSource prices coins from the last trade on Kraken's public ticker,
+// one request per coin. It only knows coins Kraken lists against the
+// currency.
+type KrakenSource struct {
+	baseURL string
+	client  *http.Client
+	retry   RetryPolicy
+}
+
+// NewKrakenSource returns a Kraken source that makes requests with client,
+// or with a default client if it is nil.
+func NewKrakenSource(client *http.Client) *KrakenSource {
+	if client == nil {
+		client = &http.Client{Timeout: priceTimeout}
+	}
+	return &KrakenSource{baseURL: krakenURL, client: client, retry: DefaultRetryPolicy()}
+}
+
+// SetRetryPolicy sets how lookups are retried after transient failures. It
+// must be called before the source is used.
+func (s *KrakenSource) SetRetryPolicy(policy RetryPolicy) {
+	s.retry = policy
+}
+
+// Name implements PriceSource.
+func (s *KrakenSource) Name() string {
+	return "kraken"
+}
+
+// Prices implements PriceSource.
+func (s *KrakenSource) Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	return pricesEach(ctx, s.Name(), coins, currency, s.price)
+}
+
+func krakenAsset(code string) string {
+	if asset, ok := krakenAssets[code]; ok {
+		return asset
+	}
+	return strings.ToUpper(code)
+}
+
+func (s *KrakenSource) price(ctx context.Context, coin, currency string) (float64, error) {
+	pair := krakenAsset(coin) + krakenAsset(currency)
+
+	// Kraken answers under its own name for the pair, e.g. XXBTZUSD for
+	// XBTUSD, so only one pair is asked for at a time.
+	var ticker struct {
+		Error  []string `json:"error"`
+		Result map[string]struct {
+			// Last is the price and volume of the last trade.
+			Last []string `json:"c"`
+		} `json:"result"`
+	}
+	if err := getPriceJSON(ctx, s.client, s.retry, s.baseURL+"?pair="+pair, &ticker); err != nil {
+		return 0, err
+	}
+	if len(ticker.Error) > 0 {
+		return 0, fmt.Errorf("%s: %s", pair, strings.Join(ticker.Error, "; "))
+	}
+	for _, t := range ticker.Result {
+		if len(t.Last) == 0 {
+			break
+		}
+		price, err := strconv.ParseFloat(t.Last[0], 64)
+		if err != nil || price <= 0 {
+			return 0, fmt.Errorf("%s: invalid price %q", pair, t.Last[0])
+		}
+		return price, nil
+	}
+	return 0, fmt.Errorf("price not found for %s", pair)
+}
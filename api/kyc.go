@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// KYC ratings grade how likely an exchange is to ask for identity documents,
+// from A (never) to D (routinely). Estimates carry the rating as KYCScore, 1
+// for A through 4 for D, with 0 meaning the exchange is not rated.
+const (
+	KYCBest  = 1
+	KYCWorst = 4
+)
+
+// kycDescriptions explains each grade, A first.
+var kycDescriptions = [...]string{
+	"never asks for identification",
+	"rarely asks; refunds are possible without identification",
+	"may hold funds until identification is provided",
+	"identification is likely to be required",
+}
+
+// KYCGrade returns the letter for a KYC score, or "?" if it is not rated.
+func KYCGrade(score int) string {
+	if score < KYCBest || score > KYCWorst {
+		return "?"
+	}
+	return string(rune('A' + score - KYCBest))
+}
+
+// KYCDescription explains what a KYC score means.
+func KYCDescription(score int) string {
+	if score < KYCBest || score > KYCWorst {
+		return "not rated"
+	}
+	return kycDescriptions[score-KYCBest]
+}
+
+// ParseKYC reads a KYC rating given as a letter (A-D) or a score (1-4).
+func ParseKYC(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) == 1 && s[0] >= 'A' && s[0] <= 'A'+KYCWorst-KYCBest {
+		return int(s[0]-'A') + KYCBest, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= KYCBest && n <= KYCWorst {
+		return n, nil
+	}
+	return 0, fmt.Errorf("invalid KYC rating %q (use A-D or 1-4)", s)
+}
+
+// FilterKYC drops estimates from exchanges rated worse than max. Unrated
+// exchanges are dropped as well, since nothing is known about them. A max of
+// 0 keeps every estimate. Like slices.DeleteFunc, it filters in place.
+func FilterKYC(estimates []Estimate, max int) []Estimate {
+	if max == 0 {
+		return estimates
+	}
+	return slices.DeleteFunc(estimates, func(e Estimate) bool {
+		return e.KYCScore < KYCBest || e.KYCScore > max
+	})
+}
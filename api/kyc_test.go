@@ -0,0 +1,55 @@
+package api
+
+import "testing"
+
+func TestParseKYC(t *testing.T) {
+	testCases := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{"A", 1, false},
+		{" c ", 3, false},
+		{"4", 4, false},
+		{"E", 0, true},
+		{"0", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseKYC(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseKYC(%q): expected error %v, got %v", tc.raw, tc.wantErr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseKYC(%q): expected %d, got %d", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestKYCGrade(t *testing.T) {
+	for score, want := range map[int]string{0: "?", 1: "A", 2: "B", 4: "D", 5: "?"} {
+		if got := KYCGrade(score); got != want {
+			t.Errorf("KYCGrade(%d): expected %s, got %s", score, want, got)
+		}
+	}
+}
+
+func TestFilterKYC(t *testing.T) {
+	estimates := []Estimate{
+		{ExchangeName: "A", KYCScore: 1},
+		{ExchangeName: "C", KYCScore: 3},
+		{ExchangeName: "Unrated"},
+		{ExchangeName: "B", KYCScore: 2},
+	}
+
+	if got := FilterKYC(estimates, 0); len(got) != 4 {
+		t.Errorf("Expected no filtering without a maximum, got %+v", got)
+	}
+
+	got := FilterKYC(estimates, 2)
+	if len(got) != 2 || got[0].ExchangeName != "A" || got[1].ExchangeName != "B" {
+		t.Errorf("Expected A and B in order, got %+v", got)
+	}
+}
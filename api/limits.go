@@ -0,0 +1,25 @@
+package api
+
+// WithinLimits reports whether the estimate's send amount is within the
+// exchange's minimum and maximum. A zero limit means there is none.
+func (e Estimate) WithinLimits() bool {
+	if e.MinAmount > 0 && e.SendAmount < e.MinAmount {
+		return false
+	}
+	if e.MaxAmount > 0 && e.SendAmount > e.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// LowestMinimum returns the smallest minimum amount among estimates, which is
+// the least that some exchange accepts, or 0 if none reports a minimum.
+func LowestMinimum(estimates []Estimate) float64 {
+	var lowest float64
+	for _, e := range estimates {
+		if e.MinAmount > 0 && (lowest == 0 || e.MinAmount < lowest) {
+			lowest = e.MinAmount
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,33 @@
+package api
+
+import "testing"
+
+func TestEstimate_WithinLimits(t *testing.T) {
+	testCases := []struct {
+		send, min, max float64
+		want           bool
+	}{
+		{1, 0, 0, true},
+		{1, 0.5, 2, true},
+		{0.4, 0.5, 2, false},
+		{3, 0.5, 2, false},
+		{3, 0.5, 0, true},
+	}
+
+	for _, tc := range testCases {
+		est := Estimate{SendAmount: tc.send, MinAmount: tc.min, MaxAmount: tc.max}
+		if got := est.WithinLimits(); got != tc.want {
+			t.Errorf("WithinLimits(send %v, min %v, max %v): expected %v, got %v", tc.send, tc.min, tc.max, tc.want, got)
+		}
+	}
+}
+
+func TestLowestMinimum(t *testing.T) {
+	estimates := []Estimate{{MinAmount: 0.02}, {MinAmount: 0}, {MinAmount: 0.01}}
+	if got := LowestMinimum(estimates); got != 0.01 {
+		t.Errorf("Expected 0.01, got %v", got)
+	}
+	if got := LowestMinimum(nil); got != 0 {
+		t.Errorf("Expected 0 without estimates, got %v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Money is an amount in a currency, such as the value of an estimate's
+// payout.
+type Money struct {
+	Amount float64
+	// Currency is a lower-case code, such as "usd", "eur" or "btc".
+	Currency string
+}
+
+// currencySymbols prefix amounts in currencies that have a common symbol.
+var currencySymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"jpy": "¥",
+}
+
+// currencyDecimals is how many decimals amounts in a currency are shown with,
+// where it is not 2.
+var currencyDecimals = map[string]int{
+	"jpy": 0,
+	"btc": 8,
+}
+
+// String formats m for display, e.g. "$601.25 USD" or "0.01850000 BTC".
+func (m Money) String() string {
+	decimals, ok := currencyDecimals[m.Currency]
+	if !ok {
+		decimals = 2
+	}
+	return fmt.Sprintf("%s%.*f %s", currencySymbols[m.Currency], decimals, m.Amount, strings.ToUpper(m.Currency))
+}
+
+// Valued reports whether the value of the payout is known. Without a price,
+// TradeValue is zero and should be shown as unknown rather than as nothing.
+func (e Estimate) Valued() bool {
+	return e.PriceSource != ""
+}
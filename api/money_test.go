@@ -0,0 +1,22 @@
+package api
+
+import "testing"
+
+func TestMoney_String(t *testing.T) {
+	testCases := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 601.254, Currency: "usd"}, "$601.25 USD"},
+		{Money{Amount: 550.1, Currency: "eur"}, "€550.10 EUR"},
+		{Money{Amount: 540, Currency: "chf"}, "540.00 CHF"},
+		{Money{Amount: 90000.4, Currency: "jpy"}, "¥90000 JPY"},
+		{Money{Amount: 0.0185, Currency: "btc"}, "0.01850000 BTC"},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.money.String(); got != tc.want {
+			t.Errorf("Expected %q, got %q", tc.want, got)
+		}
+	}
+}
@@ -1,19 +1,32 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
 )
 
+// DefaultCurrency is the currency prices are quoted in unless another one is
+// set.
+const DefaultCurrency = "usd"
+
 const (
 	coinGeckoURL   = "https://api.coingecko.com/api/v3/simple/price"
 	cacheDuration  = 5 * time.Minute
 	rateLimitDelay = 100 * time.Millisecond
+	priceTimeout   = 10 * time.Second
+	// priceBatchSize is how many coins GetPrices asks for per request, which
+	// keeps the URL well within what CoinGecko accepts.
+	priceBatchSize = 50
 )
 
 type PriceCache struct {
@@ -21,12 +34,70 @@ type PriceCache struct {
 	timestamp time.Time
 }
 
+// A PriceStore keeps cached prices between runs, such as the on-disk cache
+// in package pricecache. It may be shared by several processes.
+type PriceStore interface {
+	// Load returns every stored price.
+	Load() ([]CachedPrice, error)
+	// Save adds prices to the store, replacing older ones for the same coin
+	// and currency.
+	Save(prices []CachedPrice) error
+	// Clear removes every stored price.
+	Clear() error
+}
+
+// CachedPrice is a price as kept by a PriceStore.
+type CachedPrice struct {
+	Coin      string    `json:"coin"`
+	Currency  string    `json:"currency"`
+	Price     float64   `json:"price"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// PriceCacheStats describes the cached prices of a PriceService.
+type PriceCacheStats struct {
+	// Fresh prices are served without a lookup, Stale ones are served
+	// while they are looked up again, and Expired ones are no longer used.
+	Fresh   int
+	Stale   int
+	Expired int
+	// Oldest and Newest are when the oldest and newest prices were
+	// fetched.
+	Oldest time.Time
+	Newest time.Time
+}
+
+// cacheState says whether a cached price can be used.
+type cacheState int
+
+const (
+	cacheMiss cacheState = iota
+	cacheFresh
+	cacheStale
+)
+
+// priceKey identifies a cached price: a coin in a currency.
+type priceKey struct {
+	coin     string
+	currency string
+}
+
 type PriceService struct {
 	baseURL  string
 	client   *http.Client
-	cache    map[string]PriceCache
+	cache    map[priceKey]PriceCache
 	mutex    sync.RWMutex
 	lastCall time.Time
+	retry    RetryPolicy
+	currency string
+	batch    int
+
+	ttl        time.Duration
+	stale      time.Duration
+	store      PriceStore
+	storeOnce  sync.Once
+	refreshing map[priceKey]bool
+	refreshes  sync.WaitGroup
 }
 
 func NewPriceService() *PriceService {
@@ -35,79 +106,88 @@ func NewPriceService() *PriceService {
 
 func NewPriceServiceWithURL(baseURL string) *PriceService {
 	return &PriceService{
-		baseURL: baseURL,
-		client:  &http.Client{Timeout: 10 * time.Second},
-		cache:   make(map[string]PriceCache),
-	}
-}
-
-var coinIDMap = map[string]string{
-	"btc":   "bitcoin",
-	"eth":   "ethereum",
-	"sol":   "solana",
-	"bnb":   "binancecoin",
-	"xmr":   "monero",
-	"arrr":  "pirate-chain",
-	"zec":   "zcash",
-	"dero":  "dero",
-	"wow":   "wownero",
-	"firo":  "zcoin",
-	"zano":  "zano",
-	"dash":  "dash",
-	"bdx":   "beldex",
-	"ban":   "banano",
-	"ltc":   "litecoin",
-	"bch":   "bitcoin-cash",
-	"doge":  "dogecoin",
-	"dot":   "polkadot",
-	"link":  "chainlink",
-	"avax":  "avalanche-2",
-	"matic": "matic-network",
-	"uni":   "uniswap",
-	"shib":  "shiba-inu",
-	"etc":   "ethereum-classic",
-	"hbar":  "hedera-hashgraph",
-	"xtz":   "tezos",
-	"ada":   "cardano",
-	"xrp":   "binance-peg-xrp",
-	"trx":   "tron",
-	"atom":  "cosmos",
-	"near":  "near",
-	"apt":   "aptos",
-	"sui":   "sui",
-	"dcr":   "decred",
-	"aave":  "aave",
-	"bat":   "basic-attention-token",
-	"paxg":  "pax-gold",
-	"hive":  "hive",
-	"zen":   "horizen",
-	"scrt":  "secret",
-	"leo":   "leo-token",
-	"tusd":  "true-usd",
-	"gusd":  "gemini-dollar",
-	"nvdax": "nvidia-xstock",
-}
-
-var stablecoinMap = map[string]bool{
-	"usdt": true,
-	"usdc": true,
-	"dai":  true,
-	"busd": true,
-	"tusd": true,
-	"gusd": true,
-	"fusd": true,
-	"usdd": true,
-	"crv":  false,
+		baseURL:    baseURL,
+		client:     &http.Client{Timeout: priceTimeout},
+		cache:      make(map[priceKey]PriceCache),
+		retry:      DefaultRetryPolicy(),
+		currency:   DefaultCurrency,
+		batch:      priceBatchSize,
+		ttl:        cacheDuration,
+		refreshing: make(map[priceKey]bool),
+	}
 }
 
+// SetHTTPClient sets the HTTP client used to reach the price API, for example
+// one that routes through a proxy.
+func (s *PriceService) SetHTTPClient(client *http.Client) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.client = client
+}
+
+// SetRetryPolicy sets how price lookups are retried after transient failures.
+func (s *PriceService) SetRetryPolicy(policy RetryPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retry = policy
+}
+
+// SetCurrency sets the currency GetPrice quotes in: a fiat currency such as
+// "eur" or "chf", or a coin CoinGecko quotes against, such as "btc".
+func (s *PriceService) SetCurrency(currency string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.currency = cmp.Or(strings.ToLower(currency), DefaultCurrency)
+}
+
+// SetCacheTTL sets how long prices are served from the cache, 5 minutes by
+// default or if ttl is not positive. For staleWhileRevalidate after that, a
+// cached price is still served but looked up again in the background, so
+// callers do not wait.
+func (s *PriceService) SetCacheTTL(ttl, staleWhileRevalidate time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ttl = cmp.Or(max(ttl, 0), cacheDuration)
+	s.stale = staleWhileRevalidate
+}
+
+// SetStore keeps cached prices in store as well as in memory, so they
+// outlive the service. Prices saved there are loaded on first use; call it
+// before any lookup.
+func (s *PriceService) SetStore(store PriceStore) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.store = store
+}
+
+// Currency returns the currency GetPrice quotes in.
+func (s *PriceService) Currency() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.currency
+}
+
+// GetPrice returns the price of coin in the service's currency.
 func (s *PriceService) GetPrice(ctx context.Context, coin string) (float64, error) {
+	return s.GetPriceIn(ctx, coin, s.Currency())
+}
+
+// GetPriceIn returns the price of coin in currency, or in DefaultCurrency
+// if currency is empty. Prices are cached per coin and currency.
+func (s *PriceService) GetPriceIn(ctx context.Context, coin, currency string) (float64, error) {
 	coinLower := strings.ToLower(coin)
+	currency = cmp.Or(strings.ToLower(currency), DefaultCurrency)
 
-	if stablecoinMap[coinLower] {
+	if peggedPrice(coinLower, currency) {
 		return 1.0, nil
 	}
 
-	if price, found := s.getCachedPrice(coinLower); found {
+	key := priceKey{coin: coinLower, currency: currency}
+	switch price, state := s.getCachedPrice(key); state {
+	case cacheFresh:
+		return price, nil
+	case cacheStale:
+		s.revalidate(ctx, []string{coinLower}, currency)
 		return price, nil
 	}
 
@@ -118,86 +198,201 @@ func (s *PriceService) GetPrice(ctx context.Context, coin string) (float64, erro
 		return 0, fmt.Errorf("unknown coin: %s", coin)
 	}
 
-	price, err := s.fetchFromCoinGecko(ctx, coinID)
+	result, err := s.fetchFromCoinGecko(ctx, []string{coinID}, currency)
 	if err != nil {
 		return 0, err
 	}
+	price, ok := result[coinID][currency]
+	if !ok {
+		return 0, fmt.Errorf("price not found for %s in %s", coinID, currency)
+	}
 
-	s.cachePrice(coinLower, price)
+	s.cachePrices(map[priceKey]float64{key: price})
 
 	return price, nil
 }
 
-func (s *PriceService) getCachedPrice(coin string) (float64, bool) {
+func (s *PriceService) getCachedPrice(key priceKey) (float64, cacheState) {
+	s.loadStore()
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	cache, found := s.cache[coin]
+	cache, found := s.cache[key]
 	if !found {
-		return 0, false
+		return 0, cacheMiss
 	}
 
-	if time.Since(cache.timestamp) < cacheDuration {
-		return cache.price, true
+	switch age := time.Since(cache.timestamp); {
+	case age < s.ttl:
+		return cache.price, cacheFresh
+	case age < s.ttl+s.stale:
+		return cache.price, cacheStale
 	}
 
-	return 0, false
+	return 0, cacheMiss
 }
 
-func (s *PriceService) cachePrice(coin string, price float64) {
+// cachePrices caches prices fetched just now, in memory and in the store.
+func (s *PriceService) cachePrices(prices map[priceKey]float64) {
+	now := time.Now()
+	saved := make([]CachedPrice, 0, len(prices))
+
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	for key, price := range prices {
+		s.cache[key] = PriceCache{
+			price:     price,
+			timestamp: now,
+		}
+		saved = append(saved, CachedPrice{Coin: key.coin, Currency: key.currency, Price: price, FetchedAt: now})
+	}
+	store := s.store
+	s.mutex.Unlock()
 
-	s.cache[coin] = PriceCache{
-		price:     price,
-		timestamp: time.Now(),
+	if store != nil && len(saved) > 0 {
+		// The store only saves lookups for later runs; failing to write it
+		// does not affect the prices returned now.
+		_ = store.Save(saved)
 	}
 }
 
-func (s *PriceService) rateLimit() {
+// loadStore fills the cache from the store the first time it is used.
+// Prices already in memory are kept if they are newer.
+func (s *PriceService) loadStore() {
+	s.storeOnce.Do(func() {
+		s.mutex.RLock()
+		store := s.store
+		s.mutex.RUnlock()
+		if store == nil {
+			return
+		}
+
+		// An unreadable store is treated as empty; it is rewritten on the
+		// next save.
+		stored, err := store.Load()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, p := range stored {
+			key := priceKey{coin: strings.ToLower(p.Coin), currency: strings.ToLower(p.Currency)}
+			if cached, ok := s.cache[key]; ok && cached.timestamp.After(p.FetchedAt) {
+				continue
+			}
+			s.cache[key] = PriceCache{price: p.Price, timestamp: p.FetchedAt}
+		}
+	})
+}
+
+// revalidate looks stale prices up again in the background. Coins that are
+// already being looked up are skipped.
+func (s *PriceService) revalidate(ctx context.Context, coins []string, currency string) {
+	var keys []priceKey
+	var todo []string
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	for _, coin := range coins {
+		key := priceKey{coin: strings.ToLower(coin), currency: currency}
+		if !s.refreshing[key] {
+			s.refreshing[key] = true
+			keys = append(keys, key)
+			todo = append(todo, coin)
+		}
+	}
+	s.mutex.Unlock()
+	if len(todo) == 0 {
+		return
+	}
+
+	// The refresh outlives the request that found the price stale.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), priceTimeout)
+	s.refreshes.Go(func() {
+		defer cancel()
+		s.fetchPrices(ctx, todo, currency)
 
-	elapsed := time.Since(s.lastCall)
-	if elapsed < rateLimitDelay {
-		time.Sleep(rateLimitDelay - elapsed)
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, key := range keys {
+			delete(s.refreshing, key)
+		}
+	})
+}
+
+// Wait blocks until the background lookups started for stale prices have
+// finished. Programs that exit after a request should call it first, or the
+// refreshed prices never reach the store.
+func (s *PriceService) Wait() {
+	s.refreshes.Wait()
+}
+
+// rateLimit spaces calls to CoinGecko rateLimitDelay apart. Each caller
+// reserves its slot under the lock and waits for it without holding the lock,
+// so cache readers are not held up.
+func (s *PriceService) rateLimit() {
+	s.mutex.Lock()
+	now := time.Now()
+	next := s.lastCall.Add(rateLimitDelay)
+	if next.Before(now) {
+		next = now
 	}
-	s.lastCall = time.Now()
+	s.lastCall = next
+	s.mutex.Unlock()
+
+	time.Sleep(next.Sub(now))
 }
 
-func (s *PriceService) fetchFromCoinGecko(ctx context.Context, coinID string) (float64, error) {
-	url := fmt.Sprintf("%s?ids=%s&vs_currencies=usd", s.baseURL, coinID)
+// fetchFromCoinGecko asks for the prices of every coin in ids in each of the
+// currencies, in one request. The result maps coin IDs to prices by currency;
+// coins CoinGecko does not know are missing from it.
+func (s *PriceService) fetchFromCoinGecko(ctx context.Context, ids []string, currencies ...string) (map[string]map[string]float64, error) {
+	s.mutex.RLock()
+	policy := s.retry
+	s.mutex.RUnlock()
+
+	var result map[string]map[string]float64
+	err := policy.do(ctx, func() (retryAttempt, error) {
+		var (
+			attempt retryAttempt
+			err     error
+		)
+		result, attempt, err = s.fetchOnce(ctx, ids, currencies)
+		return attempt, err
+	})
+	return result, err
+}
+
+func (s *PriceService) fetchOnce(ctx context.Context, ids, currencies []string) (map[string]map[string]float64, retryAttempt, error) {
+	url := fmt.Sprintf("%s?ids=%s&vs_currencies=%s", s.baseURL, strings.Join(ids, ","), strings.Join(currencies, ","))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, retryAttempt{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	s.mutex.RLock()
+	client := s.client
+	s.mutex.RUnlock()
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("API request failed: %w", err)
+		return nil, retryAttempt{retry: ctx.Err() == nil}, fmt.Errorf("API request failed: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
+		attempt := retryResponse(resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")))
 		if resp.StatusCode == 429 {
-			return 0, fmt.Errorf("rate limit exceeded")
+			return nil, attempt, fmt.Errorf("rate limit exceeded")
 		}
-		return 0, fmt.Errorf("API returned status: %d", resp.StatusCode)
+		return nil, attempt, fmt.Errorf("API returned status: %d", resp.StatusCode)
 	}
 
 	var result map[string]map[string]float64
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if data, ok := result[coinID]; ok {
-		if price, ok := data["usd"]; ok {
-			return price, nil
-		}
+		return nil, retryAttempt{}, fmt.Errorf("failed to parse response: %w", err)
 	}
-
-	return 0, fmt.Errorf("price not found for %s", coinID)
+	return result, retryAttempt{}, nil
 }
 
 func GetPrice(ctx context.Context, coin string) (float64, error) {
@@ -205,42 +400,156 @@ func GetPrice(ctx context.Context, coin string) (float64, error) {
 	return service.GetPrice(ctx, coin)
 }
 
+// getCoinGeckoID looks coin up in the coin registry.
 func getCoinGeckoID(coin string) string {
-	return coinIDMap[strings.ToLower(coin)]
+	return coins.Default().CoinGeckoID(coin)
 }
 
+// IsStablecoin reports whether the coin registry lists coin as pegged to a
+// currency.
 func IsStablecoin(coin string) bool {
-	return stablecoinMap[strings.ToLower(coin)]
+	return coins.Default().Peg(coin) != ""
 }
 
+// GetPrices returns the prices of coins in the service's currency, as
+// GetPricesIn does.
 func (s *PriceService) GetPrices(ctx context.Context, coins []string) (map[string]float64, error) {
+	return s.GetPricesIn(ctx, coins, s.Currency())
+}
+
+// GetPricesIn returns the prices of coins in currency, keyed as given. Coins
+// that are not cached are looked up together, priceBatchSize per request, and
+// every price returned is cached. Coins whose price cannot be found are left
+// out; an error is only returned if none is found.
+func (s *PriceService) GetPricesIn(ctx context.Context, coins []string, currency string) (map[string]float64, error) {
+	currency = cmp.Or(strings.ToLower(currency), DefaultCurrency)
 	prices := make(map[string]float64)
-	var errors []error
 
+	var missing, stale []string
 	for _, coin := range coins {
-		price, err := s.GetPrice(ctx, coin)
-		if err != nil {
-			errors = append(errors, fmt.Errorf("%s: %w", coin, err))
+		coinLower := strings.ToLower(coin)
+		if peggedPrice(coinLower, currency) {
+			prices[coin] = 1.0
 			continue
 		}
+		price, state := s.getCachedPrice(priceKey{coin: coinLower, currency: currency})
+		switch state {
+		case cacheFresh:
+			prices[coin] = price
+		case cacheStale:
+			prices[coin] = price
+			stale = append(stale, coin)
+		default:
+			missing = append(missing, coin)
+		}
+	}
+	if len(stale) > 0 {
+		s.revalidate(ctx, stale, currency)
+	}
+
+	fetched, errs := s.fetchPrices(ctx, missing, currency)
+	for coin, price := range fetched {
 		prices[coin] = price
 	}
 
-	if len(errors) > 0 && len(prices) == 0 {
-		return nil, fmt.Errorf("all price lookups failed: %v", errors)
+	if len(errs) > 0 && len(prices) == 0 {
+		return nil, fmt.Errorf("all price lookups failed: %w", errors.Join(errs...))
 	}
 
 	return prices, nil
 }
 
-func (s *PriceService) ClearCache() {
+// fetchPrices looks coins up in currency, priceBatchSize per request, and
+// caches every price found. The result is keyed by coin as given.
+func (s *PriceService) fetchPrices(ctx context.Context, coins []string, currency string) (map[string]float64, []error) {
+	prices := make(map[string]float64)
+	found := make(map[priceKey]float64)
+	var errs []error
+
+	// Coins by CoinGecko ID. Several tickers can share an ID.
+	var ids []string
+	pending := make(map[string][]string)
+	for _, coin := range coins {
+		coinID := getCoinGeckoID(coin)
+		if coinID == "" {
+			errs = append(errs, fmt.Errorf("%s: unknown coin", coin))
+			continue
+		}
+		if _, ok := pending[coinID]; !ok {
+			ids = append(ids, coinID)
+		}
+		pending[coinID] = append(pending[coinID], coin)
+	}
+
+	for batch := range slices.Chunk(ids, max(s.batch, 1)) {
+		s.rateLimit()
+		result, err := s.fetchFromCoinGecko(ctx, batch, currency)
+		for _, coinID := range batch {
+			price, ok := result[coinID][currency]
+			for _, coin := range pending[coinID] {
+				switch {
+				case err != nil:
+					errs = append(errs, fmt.Errorf("%s: %w", coin, err))
+				case !ok:
+					errs = append(errs, fmt.Errorf("%s: price not found for %s in %s", coin, coinID, currency))
+				default:
+					found[priceKey{coin: strings.ToLower(coin), currency: currency}] = price
+					prices[coin] = price
+				}
+			}
+		}
+	}
+	s.cachePrices(found)
+
+	return prices, errs
+}
+
+// ClearCache drops every cached price, including those in the store.
+func (s *PriceService) ClearCache() error {
+	s.loadStore()
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.cache = make(map[string]PriceCache)
+	s.cache = make(map[priceKey]PriceCache)
+	if s.store != nil {
+		return s.store.Clear()
+	}
+	return nil
 }
 
+// CacheSize returns how many prices are cached, including those in the
+// store.
 func (s *PriceService) CacheSize() int {
+	s.loadStore()
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	return len(s.cache)
 }
+
+// CacheStats counts the cached prices, including those in the store, by
+// whether they are still used.
+func (s *PriceService) CacheStats() PriceCacheStats {
+	s.loadStore()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var stats PriceCacheStats
+	for _, cached := range s.cache {
+		switch age := time.Since(cached.timestamp); {
+		case age < s.ttl:
+			stats.Fresh++
+		case age < s.ttl+s.stale:
+			stats.Stale++
+		default:
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || cached.timestamp.Before(stats.Oldest) {
+			stats.Oldest = cached.timestamp
+		}
+		if cached.timestamp.After(stats.Newest) {
+			stats.Newest = cached.timestamp
+		}
+	}
+	return stats
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -68,8 +70,8 @@ func TestGetPrice_CacheHit(t *testing.T) {
 
 	cachedPrice := 3250.00
 	testService := NewPriceServiceWithURL(server.URL)
-	testService.cache = map[string]PriceCache{
-		"eth": {
+	testService.cache = map[priceKey]PriceCache{
+		{"eth", "usd"}: {
 			price:     cachedPrice,
 			timestamp: time.Now(),
 		},
@@ -106,8 +108,8 @@ func TestGetPrice_CacheExpired(t *testing.T) {
 	defer server.Close()
 
 	testService := NewPriceServiceWithURL(server.URL)
-	testService.cache = map[string]PriceCache{
-		"ltc": {
+	testService.cache = map[priceKey]PriceCache{
+		{"ltc", "usd"}: {
 			price:     80.00,
 			timestamp: time.Now().Add(-10 * time.Minute),
 		},
@@ -180,18 +182,17 @@ func TestGetPrice_PrivacyCoins(t *testing.T) {
 }
 
 func TestGetPrices_Batch(t *testing.T) {
+	var requests []string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ids := r.URL.Query().Get("ids")
+		requests = append(requests, r.URL.Query().Get("ids"))
+		all := map[string]map[string]float64{
+			"bitcoin":  {"usd": 45250.50},
+			"ethereum": {"usd": 3250.00},
+		}
 		response := make(map[string]map[string]float64)
-
-		switch ids {
-		case "bitcoin":
-			response = map[string]map[string]float64{
-				"bitcoin": {"usd": 45250.50},
-			}
-		case "ethereum":
-			response = map[string]map[string]float64{
-				"ethereum": {"usd": 3250.00},
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if price, ok := all[id]; ok {
+				response[id] = price
 			}
 		}
 		json.NewEncoder(w).Encode(response) //nolint:errcheck
@@ -218,20 +219,67 @@ func TestGetPrices_Batch(t *testing.T) {
 	if prices["eth"] != 3250.00 {
 		t.Errorf("Expected ETH price 3250.00, got %f", prices["eth"])
 	}
+
+	if len(requests) != 1 || requests[0] != "bitcoin,ethereum" {
+		t.Errorf("Expected a single batched request, got %v", requests)
+	}
+
+	if testService.CacheSize() != 2 {
+		t.Errorf("Expected both prices to be cached, got %d", testService.CacheSize())
+	}
+	if price, err := testService.GetPrice(ctx, "eth"); err != nil || price != 3250.00 || len(requests) != 1 {
+		t.Errorf("Expected ETH from the cache, got %f (%v) after %d requests", price, err, len(requests))
+	}
+}
+
+func TestGetPrices_Chunked(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("ids"))
+		response := make(map[string]map[string]float64)
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if id != "zcash" {
+				response[id] = map[string]float64{"usd": 1}
+			}
+		}
+		json.NewEncoder(w).Encode(response) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	testService := NewPriceServiceWithURL(server.URL)
+	testService.batch = 2
+	testService.cache = map[priceKey]PriceCache{
+		{"sol", "usd"}: {price: 100, timestamp: time.Now()},
+	}
+
+	prices, err := testService.GetPrices(context.Background(), []string{"btc", "eth", "sol", "usdt", "xmr", "zec", "nope"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []string{"bitcoin,ethereum", "monero,zcash"}
+	if !slices.Equal(requests, want) {
+		t.Errorf("Expected requests %v, got %v", want, requests)
+	}
+	if len(prices) != 5 || prices["sol"] != 100 || prices["usdt"] != 1 {
+		t.Errorf("Expected prices for all but zec and nope, got %v", prices)
+	}
 }
 
 func TestPriceService_ClearCache(t *testing.T) {
 	testService := NewPriceService()
-	testService.cache = map[string]PriceCache{
-		"btc": {price: 45000, timestamp: time.Now()},
-		"eth": {price: 3000, timestamp: time.Now()},
+	testService.cache = map[priceKey]PriceCache{
+		{"btc", "usd"}: {price: 45000, timestamp: time.Now()},
+		{"eth", "usd"}: {price: 3000, timestamp: time.Now()},
 	}
 
 	if testService.CacheSize() != 2 {
 		t.Errorf("Expected cache size 2, got %d", testService.CacheSize())
 	}
 
-	testService.ClearCache()
+	if err := testService.ClearCache(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
 
 	if testService.CacheSize() != 0 {
 		t.Errorf("Expected cache size 0 after clear, got %d", testService.CacheSize())
@@ -305,3 +353,170 @@ func TestGetPrice_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected no errors in concurrent access, got %d", errorCount)
 	}
 }
+
+func TestGetPriceIn_CachesPerCurrency(t *testing.T) {
+	var currencies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		currency := r.URL.Query().Get("vs_currencies")
+		currencies = append(currencies, currency)
+		prices := map[string]float64{"usd": 45000, "eur": 41000}
+		response := map[string]map[string]float64{
+			r.URL.Query().Get("ids"): {currency: prices[currency]},
+		}
+		json.NewEncoder(w).Encode(response) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	testService := NewPriceServiceWithURL(server.URL)
+	testService.retry = RetryPolicy{}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		currency string
+		want     float64
+	}{
+		{"usd", 45000},
+		{"EUR", 41000},
+		{"eur", 41000},
+		{"btc", 1},
+	} {
+		price, err := testService.GetPriceIn(ctx, "btc", tc.currency)
+		if err != nil {
+			t.Fatalf("GetPriceIn(btc, %s): expected no error, got: %v", tc.currency, err)
+		}
+		if price != tc.want {
+			t.Errorf("GetPriceIn(btc, %s): expected %f, got %f", tc.currency, tc.want, price)
+		}
+	}
+	if len(currencies) != 2 || currencies[0] != "usd" || currencies[1] != "eur" {
+		t.Errorf("Expected one lookup per currency, got %v", currencies)
+	}
+}
+
+func TestGetPriceIn_StablecoinPeg(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.URL.Query().Get("ids"))
+		response := map[string]map[string]float64{
+			"tether": {"eur": 0.92},
+		}
+		json.NewEncoder(w).Encode(response) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	testService := NewPriceServiceWithURL(server.URL)
+	testService.SetCurrency("EUR")
+	ctx := context.Background()
+
+	if price, err := testService.GetPriceIn(ctx, "usdt", "usd"); err != nil || price != 1 {
+		t.Errorf("Expected USDT to be worth 1 USD without a lookup, got %f (%v)", price, err)
+	}
+	if price, err := testService.GetPrice(ctx, "usdt"); err != nil || price != 0.92 {
+		t.Errorf("Expected USDT to be priced in EUR, got %f (%v)", price, err)
+	}
+	if len(ids) != 1 || ids[0] != "tether" {
+		t.Errorf("Expected a single lookup for tether, got %v", ids)
+	}
+}
+
+// memoryStore is a PriceStore kept in memory.
+type memoryStore struct {
+	mutex  sync.Mutex
+	prices []CachedPrice
+	saves  int
+}
+
+func (m *memoryStore) Load() ([]CachedPrice, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return slices.Clone(m.prices), nil
+}
+
+func (m *memoryStore) Save(prices []CachedPrice) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.prices = append(m.prices, prices...)
+	m.saves++
+	return nil
+}
+
+func (m *memoryStore) Clear() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.prices = nil
+	return nil
+}
+
+func TestPriceService_Store(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]map[string]float64{"monero": {"usd": 150}}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	store := &memoryStore{prices: []CachedPrice{
+		{Coin: "btc", Currency: "usd", Price: 64000, FetchedAt: time.Now().Add(-time.Minute)},
+		{Coin: "eth", Currency: "usd", Price: 3000, FetchedAt: time.Now().Add(-time.Hour)},
+	}}
+	testService := NewPriceServiceWithURL(server.URL)
+	testService.SetStore(store)
+
+	prices, err := testService.GetPrices(context.Background(), []string{"btc", "xmr"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if prices["btc"] != 64000 || prices["xmr"] != 150 {
+		t.Errorf("Expected btc from the store and xmr from the API, got %v", prices)
+	}
+	if requests != 1 || store.saves != 1 || len(store.prices) != 3 {
+		t.Errorf("Expected one request and its price saved, got %d requests and %v", requests, store.prices)
+	}
+
+	stats := testService.CacheStats()
+	if testService.CacheSize() != 3 || stats.Fresh != 2 || stats.Expired != 1 {
+		t.Errorf("Expected 2 fresh and 1 expired price, got %+v", stats)
+	}
+
+	if err := testService.ClearCache(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if testService.CacheSize() != 0 || len(store.prices) != 0 {
+		t.Errorf("Expected the store to be cleared too, got %v", store.prices)
+	}
+}
+
+func TestGetPrice_StaleWhileRevalidate(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]map[string]float64{"bitcoin": {"usd": 65000}}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	testService := NewPriceServiceWithURL(server.URL)
+	testService.SetCacheTTL(time.Minute, time.Hour)
+	testService.cache = map[priceKey]PriceCache{
+		{"btc", "usd"}: {price: 64000, timestamp: time.Now().Add(-10 * time.Minute)},
+		{"eth", "usd"}: {price: 3000, timestamp: time.Now().Add(-2 * time.Hour)},
+	}
+
+	price, err := testService.GetPrice(context.Background(), "btc")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if price != 64000 {
+		t.Errorf("Expected the stale price to be served, got %f", price)
+	}
+
+	testService.Wait()
+	if requests != 1 {
+		t.Errorf("Expected one background lookup, got %d", requests)
+	}
+	if price, _ := testService.GetPrice(context.Background(), "btc"); price != 65000 {
+		t.Errorf("Expected the refreshed price, got %f", price)
+	}
+	if stats := testService.CacheStats(); stats.Fresh != 1 || stats.Expired != 1 {
+		t.Errorf("Expected the price too old to serve to be expired, got %+v", stats)
+	}
+}
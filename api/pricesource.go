@@ -0,0 +1,298 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
+)
+
+// A PriceSource looks up market prices. PriceService (CoinGecko),
+// CoinPaprikaSource, KrakenSource and StaticPriceSource are the built-in
+// sources; a PriceChain combines several.
+type PriceSource interface {
+	// Name identifies the source, such as "coingecko".
+	Name() string
+	// Prices returns the prices of coins in currency, keyed by coin as
+	// given. Coins the source cannot price are left out; an error is only
+	// returned when none is found.
+	Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error)
+}
+
+// A PriceQuote is the price of one unit of a coin and where it came from.
+type PriceQuote struct {
+	Price float64
+	// Source names the PriceSource that gave the price.
+	Source string
+}
+
+// peggedPrice reports whether coin is worth exactly 1 in currency, because it
+// is that currency or, in the coin registry, a stablecoin pegged to it.
+// Stablecoins are priced like other coins in any other currency.
+func peggedPrice(coin, currency string) bool {
+	return coin == currency || coins.Default().Peg(coin) == currency
+}
+
+// Name implements PriceSource.
+func (s *PriceService) Name() string {
+	return "coingecko"
+}
+
+// Prices implements PriceSource with GetPricesIn.
+func (s *PriceService) Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	prices, err := s.GetPricesIn(ctx, coins, currency)
+	if err != nil {
+		return nil, err
+	}
+	return quotesFrom(prices, s.Name()), nil
+}
+
+func quotesFrom(prices map[string]float64, source string) map[string]PriceQuote {
+	quotes := make(map[string]PriceQuote, len(prices))
+	for coin, price := range prices {
+		quotes[coin] = PriceQuote{Price: price, Source: source}
+	}
+	return quotes
+}
+
+// pricesEach prices coins one at a time with lookup, for sources that take a
+// request per coin. Coins pegged to currency are priced without a lookup.
+func pricesEach(ctx context.Context, name string, coins []string, currency string, lookup func(ctx context.Context, coin, currency string) (float64, error)) (map[string]PriceQuote, error) {
+	currency = cmp.Or(strings.ToLower(currency), DefaultCurrency)
+	quotes := make(map[string]PriceQuote)
+	var errs []error
+	for _, coin := range coins {
+		if _, done := quotes[coin]; done {
+			continue
+		}
+		coinLower := strings.ToLower(coin)
+		if peggedPrice(coinLower, currency) {
+			quotes[coin] = PriceQuote{Price: 1.0, Source: name}
+			continue
+		}
+		price, err := lookup(ctx, coinLower, currency)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", coin, err))
+			continue
+		}
+		quotes[coin] = PriceQuote{Price: price, Source: name}
+	}
+	if len(errs) > 0 && len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: all price lookups failed: %w", name, errors.Join(errs...))
+	}
+	return quotes, nil
+}
+
+// PriceChain prices coins from several sources. By default each source is
+// asked in turn for the coins the ones before it could not price, so later
+// sources are fallbacks. With SetMedian every source is asked for every coin
+// and the median of their prices is used. A PriceChain is safe for
+// concurrent use.
+type PriceChain struct {
+	sources []PriceSource
+	mutex   sync.RWMutex
+	median  bool
+}
+
+// NewPriceChain returns a chain of sources, tried in the order given.
+func NewPriceChain(sources ...PriceSource) *PriceChain {
+	return &PriceChain{sources: sources}
+}
+
+// SetMedian sets whether prices are the median across all sources rather
+// than the first one found.
+func (c *PriceChain) SetMedian(median bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.median = median
+}
+
+// Name implements PriceSource. It lists the chain's sources, e.g.
+// "coingecko,kraken".
+func (c *PriceChain) Name() string {
+	names := make([]string, len(c.sources))
+	for i, s := range c.sources {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Prices implements PriceSource. Each quote names the source that priced it
+// or, for medians, every source that contributed, as in
+// "median(coingecko,kraken)".
+func (c *PriceChain) Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	c.mutex.RLock()
+	median := c.median
+	c.mutex.RUnlock()
+
+	if len(c.sources) == 0 {
+		return nil, fmt.Errorf("no price sources configured")
+	}
+	if median {
+		return c.medianPrices(ctx, coins, currency)
+	}
+
+	quotes := make(map[string]PriceQuote)
+	var errs []error
+	remaining := coins
+	for _, source := range c.sources {
+		found, err := source.Prices(ctx, remaining, currency)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for coin, quote := range found {
+			quotes[coin] = quote
+		}
+		remaining = slices.DeleteFunc(slices.Clone(remaining), func(coin string) bool {
+			_, ok := quotes[coin]
+			return ok
+		})
+		if len(remaining) == 0 {
+			return quotes, nil
+		}
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("all price sources failed: %w", errors.Join(errs...))
+	}
+	return quotes, nil
+}
+
+// medianPrices asks every source at once and takes the median per coin.
+func (c *PriceChain) medianPrices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	results := make([]map[string]PriceQuote, len(c.sources))
+	errs := make([]error, len(c.sources))
+	var wg sync.WaitGroup
+	for i, source := range c.sources {
+		wg.Go(func() {
+			results[i], errs[i] = source.Prices(ctx, coins, currency)
+		})
+	}
+	wg.Wait()
+
+	quotes := make(map[string]PriceQuote)
+	for _, coin := range coins {
+		var prices []float64
+		var names []string
+		for i, found := range results {
+			if quote, ok := found[coin]; ok {
+				prices = append(prices, quote.Price)
+				names = append(names, c.sources[i].Name())
+			}
+		}
+		switch len(prices) {
+		case 0:
+		case 1:
+			quotes[coin] = PriceQuote{Price: prices[0], Source: names[0]}
+		default:
+			quotes[coin] = PriceQuote{
+				Price:  medianOf(prices),
+				Source: "median(" + strings.Join(names, ",") + ")",
+			}
+		}
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("all price sources failed: %w", errors.Join(errs...))
+	}
+	return quotes, nil
+}
+
+// medianOf returns the median of values, averaging the middle two of an even
+// count. It sorts values in place.
+func medianOf(values []float64) float64 {
+	slices.Sort(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// StaticPriceSource prices coins from a fixed table, such as prices kept in
+// a file for offline use.
+type StaticPriceSource struct {
+	prices map[string]map[string]float64
+}
+
+// NewStaticPriceSource returns a source with prices by coin, then currency.
+// Coin and currency codes are matched case-insensitively.
+func NewStaticPriceSource(prices map[string]map[string]float64) *StaticPriceSource {
+	normalized := make(map[string]map[string]float64, len(prices))
+	for coin, byCurrency := range prices {
+		m := make(map[string]float64, len(byCurrency))
+		for currency, price := range byCurrency {
+			m[strings.ToLower(currency)] = price
+		}
+		normalized[strings.ToLower(coin)] = m
+	}
+	return &StaticPriceSource{prices: normalized}
+}
+
+// LoadStaticPriceSource reads prices from a JSON file laid out as
+// {"btc": {"usd": 64000, "eur": 59000}, ...}.
+func LoadStaticPriceSource(path string) (*StaticPriceSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price file: %w", err)
+	}
+	var prices map[string]map[string]float64
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price file %s: %w", path, err)
+	}
+	return NewStaticPriceSource(prices), nil
+}
+
+// Name implements PriceSource.
+func (s *StaticPriceSource) Name() string {
+	return "file"
+}
+
+// Prices implements PriceSource.
+func (s *StaticPriceSource) Prices(ctx context.Context, coins []string, currency string) (map[string]PriceQuote, error) {
+	return pricesEach(ctx, s.Name(), coins, currency, func(_ context.Context, coin, currency string) (float64, error) {
+		price, ok := s.prices[coin][currency]
+		if !ok || price <= 0 {
+			return 0, fmt.Errorf("no price in %s", currency)
+		}
+		return price, nil
+	})
+}
+
+// getPriceJSON fetches url with client and decodes the JSON response into v,
+// retrying transient failures as policy allows.
+func getPriceJSON(ctx context.Context, client *http.Client, policy RetryPolicy, url string, v any) error {
+	return policy.do(ctx, func() (retryAttempt, error) {
+		return getPriceJSONOnce(ctx, client, url, v)
+	})
+}
+
+func getPriceJSONOnce(ctx context.Context, client *http.Client, url string, v any) (retryAttempt, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return retryAttempt{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return retryAttempt{retry: ctx.Err() == nil}, fmt.Errorf("API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		attempt := retryResponse(resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return attempt, fmt.Errorf("rate limit exceeded")
+		}
+		return attempt, fmt.Errorf("API returned status: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return retryAttempt{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return retryAttempt{}, nil
+}
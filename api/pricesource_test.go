@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// failingSource is a PriceSource that knows no prices.
+type failingSource struct{}
+
+func (failingSource) Name() string { return "down" }
+
+func (failingSource) Prices(context.Context, []string, string) (map[string]PriceQuote, error) {
+	return nil, fmt.Errorf("rate limit exceeded")
+}
+
+func TestPriceChain_FallsBack(t *testing.T) {
+	first := NewStaticPriceSource(map[string]map[string]float64{"btc": {"usd": 60000}})
+	second := NewStaticPriceSource(map[string]map[string]float64{"btc": {"usd": 1}, "XMR": {"USD": 150}})
+	chain := NewPriceChain(failingSource{}, first, second)
+
+	quotes, err := chain.Prices(context.Background(), []string{"btc", "xmr", "nope"}, "usd")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := quotes["btc"]; got != (PriceQuote{Price: 60000, Source: "file"}) {
+		t.Errorf("Expected btc from the first source that knows it, got %+v", got)
+	}
+	if got := quotes["xmr"]; got.Price != 150 {
+		t.Errorf("Expected xmr from the fallback, got %+v", got)
+	}
+	if _, ok := quotes["nope"]; ok {
+		t.Errorf("Expected unknown coin to be left out, got %+v", quotes["nope"])
+	}
+
+	if _, err := NewPriceChain(failingSource{}).Prices(context.Background(), []string{"btc"}, "usd"); err == nil {
+		t.Error("Expected an error when every source fails")
+	}
+}
+
+func TestPriceChain_Median(t *testing.T) {
+	chain := NewPriceChain(
+		NewStaticPriceSource(map[string]map[string]float64{"btc": {"usd": 100}, "xmr": {"usd": 150}}),
+		failingSource{},
+		NewStaticPriceSource(map[string]map[string]float64{"btc": {"usd": 110}}),
+		NewStaticPriceSource(map[string]map[string]float64{"btc": {"usd": 300}}),
+	)
+	chain.SetMedian(true)
+
+	quotes, err := chain.Prices(context.Background(), []string{"btc", "xmr"}, "usd")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := quotes["btc"]; got != (PriceQuote{Price: 110, Source: "median(file,file,file)"}) {
+		t.Errorf("Expected the median of three prices, got %+v", got)
+	}
+	if got := quotes["xmr"]; got != (PriceQuote{Price: 150, Source: "file"}) {
+		t.Errorf("Expected the only price for xmr, got %+v", got)
+	}
+
+	if got := medianOf([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Errorf("Expected 2.5 for an even count, got %f", got)
+	}
+}
+
+func TestLoadStaticPriceSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"btc": {"usd": 64000, "eur": 59000}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source, err := LoadStaticPriceSource(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	quotes, err := source.Prices(context.Background(), []string{"BTC", "usdt"}, "EUR")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if quotes["BTC"].Price != 59000 {
+		t.Errorf("Expected 59000, got %+v", quotes["BTC"])
+	}
+	if _, ok := quotes["usdt"]; ok {
+		t.Errorf("Expected no EUR price for a USD stablecoin, got %+v", quotes["usdt"])
+	}
+
+	if _, err := LoadStaticPriceSource(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestCoinPaprikaSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xmr-monero" || r.URL.Query().Get("quotes") != "EUR" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"id": "xmr-monero", "quotes": {"EUR": {"price": 140.5}}}`)
+	}))
+	defer server.Close()
+
+	source := NewCoinPaprikaSource(server.Client())
+	source.baseURL = server.URL
+	quotes, err := source.Prices(context.Background(), []string{"xmr", "eurc"}, "eur")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := quotes["xmr"]; got != (PriceQuote{Price: 140.5, Source: "coinpaprika"}) {
+		t.Errorf("Expected 140.5 from coinpaprika, got %+v", got)
+	}
+	if quotes["eurc"].Price != 1 {
+		t.Errorf("Expected EUR stablecoin at 1, got %+v", quotes["eurc"])
+	}
+}
+
+func TestCoinPaprikaSource_Retry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"id": "btc-bitcoin", "quotes": {"USD": {"price": 64000}}}`)
+	}))
+	defer server.Close()
+
+	source := NewCoinPaprikaSource(server.Client())
+	source.baseURL = server.URL
+	source.SetRetryPolicy(RetryPolicy{Retries: 1})
+	quotes, err := source.Prices(context.Background(), []string{"btc"}, "usd")
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got: %v", err)
+	}
+	if quotes["btc"].Price != 64000 {
+		t.Errorf("Expected 64000, got %+v", quotes["btc"])
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestKrakenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("pair") {
+		case "XBTUSD":
+			fmt.Fprint(w, `{"error": [], "result": {"XXBTZUSD": {"c": ["64012.3", "0.01"]}}}`)
+		default:
+			fmt.Fprint(w, `{"error": ["EQuery:Unknown asset pair"]}`)
+		}
+	}))
+	defer server.Close()
+
+	source := NewKrakenSource(server.Client())
+	source.baseURL = server.URL
+	quotes, err := source.Prices(context.Background(), []string{"btc", "arrr"}, "usd")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := quotes["btc"]; got != (PriceQuote{Price: 64012.3, Source: "kraken"}) {
+		t.Errorf("Expected the last trade price, got %+v", got)
+	}
+	if _, ok := quotes["arrr"]; ok {
+		t.Errorf("Expected an unlisted pair to be left out, got %+v", quotes["arrr"])
+	}
+
+	if _, err := source.Prices(context.Background(), []string{"arrr"}, "usd"); err == nil {
+		t.Error("Expected an error when no pair is listed")
+	}
+}
@@ -0,0 +1,23 @@
+package api
+
+import "context"
+
+// SwapProvider is the swap backend: it quotes, creates and follows trades.
+// Client implements it against the CypherGoat API; apitest.Fake implements it
+// in memory for tests.
+type SwapProvider interface {
+	// Estimate quotes a swap with every partner exchange, best offer first.
+	Estimate(ctx context.Context, req EstimateRequest) ([]Estimate, error)
+
+	// CreateTrade creates a trade with the partner exchange named in req.
+	CreateTrade(ctx context.Context, req TradeRequest) (Transaction, error)
+
+	// GetTransaction fetches a transaction by its Id or CGID.
+	GetTransaction(ctx context.Context, id string) (Transaction, error)
+
+	// Track refreshes t, normalizing its status and setting Done once the
+	// status is terminal.
+	Track(ctx context.Context, t Transaction) (Transaction, error)
+}
+
+var _ SwapProvider = (*Client)(nil)
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// A Ranker orders estimates in place, best first. Client.Estimate ranks its
+// results with the Ranker given to WithRanker, or with ByAmount (ByDeposit
+// for reverse quotes) by default.
+type Ranker interface {
+	Rank(estimates []Estimate)
+}
+
+// RankFunc turns a comparison into a Ranker. It returns a negative number when
+// a ranks before b. Equal estimates keep their order.
+type RankFunc func(a, b Estimate) int
+
+// Rank implements Ranker.
+func (f RankFunc) Rank(estimates []Estimate) {
+	slices.SortStableFunc(estimates, f)
+}
+
+// Built-in rankers.
+var (
+	// ByAmount ranks the largest payout first.
+	ByAmount Ranker = RankFunc(func(a, b Estimate) int {
+		return cmp.Or(
+			cmp.Compare(b.ReceiveAmount, a.ReceiveAmount),
+			cmp.Compare(a.SendAmount, b.SendAmount),
+		)
+	})
+
+	// ByDeposit ranks the smallest deposit first, which is what matters
+	// for reverse quotes, where every offer pays out the same amount.
+	ByDeposit Ranker = RankFunc(func(a, b Estimate) int {
+		return cmp.Or(
+			cmp.Compare(a.SendAmount, b.SendAmount),
+			cmp.Compare(b.ReceiveAmount, a.ReceiveAmount),
+		)
+	})
+
+	// ByValue ranks the payout worth the most first. Offers without a known
+	// value rank last, by amount. All estimates must be valued in the same
+	// currency, as those from one Client are.
+	ByValue Ranker = RankFunc(func(a, b Estimate) int {
+		return cmp.Or(
+			cmp.Compare(b.TradeValue.Amount, a.TradeValue.Amount),
+			cmp.Compare(b.ReceiveAmount, a.ReceiveAmount),
+		)
+	})
+
+	// ByKYC ranks the exchanges least likely to ask for identification
+	// first, unrated ones last, and by amount within a rating.
+	ByKYC Ranker = RankFunc(func(a, b Estimate) int {
+		return cmp.Or(
+			cmp.Compare(kycRisk(a.KYCScore), kycRisk(b.KYCScore)),
+			cmp.Compare(b.ReceiveAmount, a.ReceiveAmount),
+		)
+	})
+)
+
+// kycRisk orders KYC scores with unrated exchanges after D.
+func kycRisk(score int) int {
+	if score < KYCBest || score > KYCWorst {
+		return KYCWorst + 1
+	}
+	return score
+}
+
+// unknownReliability is assumed for exchanges without a track record.
+const unknownReliability = 0.5
+
+// ByReliability ranks exchanges by their share of completed trades, from 0
+// to 1, keyed by lower-cased exchange name. Exchanges that are not listed
+// count as 0.5. Ties are ranked by amount.
+func ByReliability(reliability map[string]float64) Ranker {
+	return RankFunc(func(a, b Estimate) int {
+		return cmp.Or(
+			cmp.Compare(reliabilityOf(reliability, b), reliabilityOf(reliability, a)),
+			cmp.Compare(b.ReceiveAmount, a.ReceiveAmount),
+		)
+	})
+}
+
+func reliabilityOf(reliability map[string]float64, e Estimate) float64 {
+	if r, ok := reliability[strings.ToLower(e.ExchangeName)]; ok {
+		return r
+	}
+	return unknownReliability
+}
+
+// Weights set how much each criterion counts in a Composite ranking. Only
+// their ratios matter.
+type Weights struct {
+	Amount      float64
+	Value       float64
+	KYC         float64
+	Reliability float64
+}
+
+// DefaultWeights favor the payout, then privacy, then track record.
+var DefaultWeights = Weights{Amount: 0.6, KYC: 0.25, Reliability: 0.15}
+
+// Composite ranks estimates by a weighted score. Each criterion is scaled to
+// 0-1 across the estimates being ranked: the amount received per unit sent
+// and the value relative to the best offer, the KYC rating from A (1) to
+// D (0, as are unrated exchanges), and reliability as in ByReliability.
+func Composite(w Weights, reliability map[string]float64) Ranker {
+	return compositeRanker{weights: w, reliability: reliability}
+}
+
+type compositeRanker struct {
+	weights     Weights
+	reliability map[string]float64
+}
+
+func (r compositeRanker) Rank(estimates []Estimate) {
+	var bestRate, bestValue float64
+	for _, e := range estimates {
+		bestRate = max(bestRate, e.Rate())
+		bestValue = max(bestValue, e.TradeValue.Amount)
+	}
+
+	w := r.weights
+	total := w.Amount + w.Value + w.KYC + w.Reliability
+	if total <= 0 {
+		ByAmount.Rank(estimates)
+		return
+	}
+
+	type scored struct {
+		est   Estimate
+		score float64
+	}
+	ranked := make([]scored, len(estimates))
+	for i, e := range estimates {
+		var s float64
+		if bestRate > 0 {
+			s += w.Amount * e.Rate() / bestRate
+		}
+		if bestValue > 0 {
+			s += w.Value * e.TradeValue.Amount / bestValue
+		}
+		if risk := kycRisk(e.KYCScore); risk <= KYCWorst {
+			s += w.KYC * float64(KYCWorst-risk) / float64(KYCWorst-KYCBest)
+		}
+		s += w.Reliability * reliabilityOf(r.reliability, e)
+		ranked[i] = scored{est: e, score: s / total}
+	}
+
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(b.est.ReceiveAmount, a.est.ReceiveAmount),
+		)
+	})
+	for i, s := range ranked {
+		estimates[i] = s.est
+	}
+}
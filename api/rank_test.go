@@ -0,0 +1,73 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func exchangeNames(estimates []Estimate) []string {
+	names := make([]string, len(estimates))
+	for i, e := range estimates {
+		names[i] = e.ExchangeName
+	}
+	return names
+}
+
+func TestRankers(t *testing.T) {
+	estimates := []Estimate{
+		{ExchangeName: "Cheap", SendAmount: 1, ReceiveAmount: 10, TradeValue: Money{Amount: 100, Currency: "usd"}, KYCScore: 3},
+		{ExchangeName: "Private", SendAmount: 1, ReceiveAmount: 9, TradeValue: Money{Amount: 90, Currency: "usd"}, KYCScore: 1},
+		{ExchangeName: "Unrated", SendAmount: 0.9, ReceiveAmount: 9.5, TradeValue: Money{Amount: 95, Currency: "usd"}},
+		{ExchangeName: "Best", SendAmount: 1, ReceiveAmount: 11, TradeValue: Money{Amount: 110, Currency: "usd"}, KYCScore: 4},
+	}
+	reliability := map[string]float64{"best": 0.2, "private": 0.9}
+
+	testCases := []struct {
+		name   string
+		ranker Ranker
+		want   []string
+	}{
+		{"amount", ByAmount, []string{"Best", "Cheap", "Unrated", "Private"}},
+		{"deposit", ByDeposit, []string{"Unrated", "Best", "Cheap", "Private"}},
+		{"value", ByValue, []string{"Best", "Cheap", "Unrated", "Private"}},
+		{"kyc", ByKYC, []string{"Private", "Cheap", "Best", "Unrated"}},
+		{"reliability", ByReliability(reliability), []string{"Private", "Cheap", "Unrated", "Best"}},
+		{"custom", RankFunc(func(a, b Estimate) int { return a.KYCScore - b.KYCScore }), []string{"Unrated", "Private", "Cheap", "Best"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ranked := slices.Clone(estimates)
+			tc.ranker.Rank(ranked)
+			if got := exchangeNames(ranked); !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestComposite(t *testing.T) {
+	estimates := []Estimate{
+		{ExchangeName: "Best", SendAmount: 1, ReceiveAmount: 10, KYCScore: 4},
+		{ExchangeName: "Private", SendAmount: 1, ReceiveAmount: 9.8, KYCScore: 1},
+		{ExchangeName: "Poor", SendAmount: 1, ReceiveAmount: 5, KYCScore: 1},
+	}
+
+	ranked := slices.Clone(estimates)
+	Composite(Weights{Amount: 1}, nil).Rank(ranked)
+	if got := exchangeNames(ranked); !slices.Equal(got, []string{"Best", "Private", "Poor"}) {
+		t.Errorf("Amount only: expected amount order, got %v", got)
+	}
+
+	ranked = slices.Clone(estimates)
+	Composite(DefaultWeights, nil).Rank(ranked)
+	if got := exchangeNames(ranked); !slices.Equal(got, []string{"Private", "Best", "Poor"}) {
+		t.Errorf("Default weights: expected a near-best offer without KYC first, got %v", got)
+	}
+
+	ranked = slices.Clone(estimates)
+	Composite(Weights{}, nil).Rank(ranked)
+	if got := exchangeNames(ranked); !slices.Equal(got, []string{"Best", "Private", "Poor"}) {
+		t.Errorf("Zero weights: expected amount order, got %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"strings"
+	"time"
+)
+
+// RateType says whether an estimate's rate is guaranteed.
+type RateType string
+
+const (
+	// RateFloating estimates follow the market until the deposit is
+	// exchanged, so the amount received may differ from the quote.
+	RateFloating RateType = "floating"
+	// RateFixed estimates lock the rate until they expire. A trade created
+	// with the estimate's RateID either pays the quoted amount or is refused.
+	RateFixed RateType = "fixed"
+)
+
+// ParseRateType normalizes a rate type reported by the API. Anything other
+// than a fixed rate is treated as floating, since that promises less.
+func ParseRateType(s string) RateType {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "fixed", "fix", "fixed-rate", "fixed_rate":
+		return RateFixed
+	}
+	return RateFloating
+}
+
+// Fixed reports whether the estimate has a locked rate.
+func (e Estimate) Fixed() bool {
+	return e.RateType == RateFixed
+}
+
+// Rate is the effective exchange rate: the amount received per unit sent,
+// after the exchange's fees. It is 0 when the send amount is unknown.
+func (e Estimate) Rate() float64 {
+	if e.SendAmount <= 0 {
+		return 0
+	}
+	return e.ReceiveAmount / e.SendAmount
+}
+
+// Expired reports whether a fixed-rate quote has expired at now. Estimates
+// without an expiry never expire.
+func (e Estimate) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
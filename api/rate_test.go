@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateType(t *testing.T) {
+	testCases := []struct {
+		raw  string
+		want RateType
+	}{
+		{"fixed", RateFixed},
+		{" Fixed ", RateFixed},
+		{"fixed_rate", RateFixed},
+		{"floating", RateFloating},
+		{"float", RateFloating},
+		{"", RateFloating},
+	}
+
+	for _, tc := range testCases {
+		if got := ParseRateType(tc.raw); got != tc.want {
+			t.Errorf("ParseRateType(%q): expected %s, got %s", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestEstimate_Expired(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		expiresAt time.Time
+		want      bool
+	}{
+		{time.Time{}, false},
+		{now.Add(time.Minute), false},
+		{now, true},
+		{now.Add(-time.Minute), true},
+	}
+
+	for _, tc := range testCases {
+		est := Estimate{RateType: RateFixed, ExpiresAt: tc.expiresAt}
+		if got := est.Expired(now); got != tc.want {
+			t.Errorf("Expired with expiry %v: expected %v, got %v", tc.expiresAt, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how idempotent requests are retried after transient
+// failures: network errors, timeouts and 429, 500, 502, 503 and 504
+// responses.
+type RetryPolicy struct {
+	// Retries is the number of attempts after the first. Zero disables
+	// retrying.
+	Retries int
+	// BaseDelay is the wait before the first retry. It doubles with each
+	// further retry, with jitter.
+	BaseDelay time.Duration
+	// MaxDelay caps a single wait.
+	MaxDelay time.Duration
+	// Budget caps the total time spent waiting between attempts. A retry
+	// that would exceed it is not made. Zero means no limit.
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy returns the policy clients use unless told otherwise.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Retries:   2,
+		BaseDelay: 500 * time.Millisecond,
+		MaxDelay:  10 * time.Second,
+		Budget:    30 * time.Second,
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered wait before retry number n (starting at 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay << (n - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	// Equal jitter: half fixed, half random, so concurrent clients spread out
+	// without ever retrying immediately.
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAttempt reports the outcome of one attempt to RetryPolicy.do.
+type retryAttempt struct {
+	// retry is set when the failure is transient.
+	retry bool
+	// after is the wait the server asked for, or zero.
+	after time.Duration
+}
+
+// do calls fn until it succeeds, fails permanently, or the policy is
+// exhausted, and returns fn's last error.
+func (p RetryPolicy) do(ctx context.Context, fn func() (retryAttempt, error)) error {
+	var waited time.Duration
+	for n := 1; ; n++ {
+		attempt, err := fn()
+		if err == nil || !attempt.retry || n > p.Retries {
+			return err
+		}
+		if ctx.Err() != nil {
+			// The caller gave up; transport errors are just its echo.
+			return err
+		}
+
+		delay := p.backoff(n)
+		if attempt.after > 0 {
+			delay = attempt.after
+		}
+		if p.Budget > 0 && waited+delay > p.Budget {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		waited += delay
+	}
+}
+
+// retryResponse decides whether a request that got an error status may be
+// retried, honoring Retry-After on 429 and 503 responses.
+func retryResponse(status int, retryAfter time.Duration) retryAttempt {
+	if !retryableStatus(status) {
+		return retryAttempt{}
+	}
+	attempt := retryAttempt{retry: true}
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		attempt.after = retryAfter
+	}
+	return attempt
+}
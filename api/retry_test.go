@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry retries quickly enough for tests.
+var fastRetry = RetryPolicy{Retries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// flakyServer fails the first failures requests with status, then succeeds.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, "<html>error</html>")
+			return
+		}
+		fmt.Fprint(w, `{"transaction": {"id": "abc", "status": "waiting"}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetry_TransientStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server, calls := flakyServer(t, 2, status, nil)
+			client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+
+			if _, err := client.GetTransaction(context.Background(), "abc"); err != nil {
+				t.Fatalf("Expected success after retries, got %v", err)
+			}
+			if got := calls.Load(); got != 3 {
+				t.Errorf("Expected 3 attempts, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusBadGateway, nil)
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+
+	_, err := client.GetTransaction(context.Background(), "abc")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("Expected 1 attempt plus 3 retries, got %d", got)
+	}
+}
+
+func TestRetry_PermanentError(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusBadRequest, nil)
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+
+	if _, err := client.GetTransaction(context.Background(), "abc"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected no retries for a 400, got %d attempts", got)
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+
+	start := time.Now()
+	if _, err := client.GetTransaction(context.Background(), "abc"); err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected to wait for Retry-After, waited %s", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestRetry_Budget(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusServiceUnavailable, http.Header{"Retry-After": {"60"}})
+	policy := fastRetry
+	policy.Budget = time.Second
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	start := time.Now()
+	if _, err := client.GetTransaction(context.Background(), "abc"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected to give up instead of exceeding the budget, took %s", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetry_CreateTradeNotRetried(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusBadGateway, nil)
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+
+	if _, err := client.CreateTrade(context.Background(), TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 1}); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected trade creation to be attempted once, got %d", got)
+	}
+}
+
+func TestRetry_CreateTradeWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"transaction": {"id": "abc"}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+	_, err := client.CreateTrade(context.Background(), TradeRequest{Coin1: "btc", Coin2: "xmr", Amount: 1, IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "key-1" || keys[1] != "key-1" {
+		t.Errorf("Expected the idempotency key on both attempts, got %v", keys)
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusBadGateway, nil)
+	policy := RetryPolicy{Retries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetTransaction(ctx, "abc"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected to stop waiting when the context ends, got %d attempts", got)
+	}
+}
+
+func TestPriceService_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"bitcoin": {"usd": 50000}}`)
+	}))
+	defer server.Close()
+
+	service := NewPriceServiceWithURL(server.URL)
+	service.SetRetryPolicy(fastRetry)
+
+	price, err := service.GetPrice(context.Background(), "btc")
+	if err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	if price != 50000 {
+		t.Errorf("Expected 50000, got %f", price)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		for range 20 {
+			d := p.backoff(n)
+			if d < max/2 || d > max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", n, d, max/2, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+)
+
+// Reverse quotes find the send amount that pays out a given receive amount.
+// The backend is asked first; if it answers with forward estimates instead,
+// the send amount is searched for per exchange. Every probe quotes all
+// exchanges at once, so each one narrows the search for all of them.
+
+const (
+	// reverseTolerance is how far above the requested receive amount a
+	// result may land, relative to it.
+	reverseTolerance = 0.001
+	// reverseRounds bounds the search. Payouts are close to linear in the
+	// send amount, so interpolation usually converges in two or three.
+	reverseRounds = 8
+	// reverseParallel is how many probes are sent at once.
+	reverseParallel = 4
+	// amountStep is the precision amounts are sent to the API with.
+	amountStep = 1e-6
+)
+
+// reversePoint is one exchange's estimate at a send amount.
+type reversePoint struct {
+	send float64
+	est  Estimate
+}
+
+// reverseSearch brackets the send amount one exchange needs: lo pays out
+// less than the target and hi at least the target.
+type reverseSearch struct {
+	lo, hi *reversePoint
+}
+
+func (s *reverseSearch) record(send float64, est Estimate, target float64) {
+	p := &reversePoint{send: send, est: est}
+	if est.ReceiveAmount >= target {
+		if s.hi == nil || send < s.hi.send {
+			s.hi = p
+		}
+		return
+	}
+	if s.lo == nil || send > s.lo.send {
+		s.lo = p
+	}
+}
+
+func (s *reverseSearch) done(target float64) bool {
+	if s.hi == nil {
+		return false
+	}
+	if s.hi.est.ReceiveAmount <= target*(1+reverseTolerance) {
+		return true
+	}
+	// The exchange's minimum deposit already pays out more than the target.
+	if s.hi.send <= s.hi.est.MinAmount {
+		return true
+	}
+	return s.lo != nil && s.hi.send-s.lo.send <= amountStep
+}
+
+// next returns the send amount to probe next.
+func (s *reverseSearch) next(target float64) float64 {
+	// Aim slightly above the target so that a probe on the line between
+	// the brackets does not fall short by a rounding error.
+	aim := target * (1 + reverseTolerance/2)
+
+	var x float64
+	switch {
+	case s.hi == nil && s.lo.est.ReceiveAmount <= 0:
+		// Below the exchange's minimum; nothing to extrapolate from.
+		x = max(s.lo.send*2, s.lo.est.MinAmount)
+	case s.hi == nil:
+		// Fees make payouts grow a little faster than linearly, so
+		// extrapolating from below falls short; overshoot a little.
+		x = s.lo.send * aim / s.lo.est.ReceiveAmount * 1.01
+	case s.lo == nil:
+		x = s.hi.send * aim / s.hi.est.ReceiveAmount
+	default:
+		lo, hi := s.lo, s.hi
+		x = (lo.send + hi.send) / 2
+		if lo.est.ReceiveAmount <= 0 && lo.send < hi.est.MinAmount && hi.est.MinAmount < hi.send {
+			x = hi.est.MinAmount
+		} else if lo.est.ReceiveAmount > 0 {
+			x = lo.send + (aim-lo.est.ReceiveAmount)*(hi.send-lo.send)/(hi.est.ReceiveAmount-lo.est.ReceiveAmount)
+		}
+		if x <= lo.send || x >= hi.send {
+			x = (lo.send + hi.send) / 2
+		}
+	}
+	return math.Ceil(x/amountStep) * amountStep
+}
+
+// reverseEstimate implements Estimate for req.ReceiveAmount > 0.
+func (c *Client) reverseEstimate(ctx context.Context, req EstimateRequest) ([]Estimate, error) {
+	guess := req.Amount
+	if guess <= 0 {
+		guess = c.reverseGuess(ctx, req)
+	}
+
+	results, err := c.fetchEstimates(ctx, req, guess)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := results
+	if !isNativeReverse(results) {
+		estimates, err = c.searchReverse(ctx, req, guess, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	price, source := c.price(ctx, req.Coin2)
+	estimates = populateEstimates(estimates, req.Coin1, req.Coin2, 0, req.Network1, req.Network2, price, source)
+	c.rankerFor(ByDeposit).Rank(estimates)
+	return filterFixed(estimates, req.Fixed), nil
+}
+
+// isNativeReverse reports whether the backend answered a reverse quote
+// itself, which it signals by filling in the send amounts.
+func isNativeReverse(results []Estimate) bool {
+	return len(results) > 0 && !slices.ContainsFunc(results, func(e Estimate) bool { return e.SendAmount <= 0 })
+}
+
+// reverseGuess picks the send amount to start searching from, converting the
+// receive amount at market prices when they are known.
+func (c *Client) reverseGuess(ctx context.Context, req EstimateRequest) float64 {
+	prices, err := c.prices.Prices(ctx, []string{req.Coin1, req.Coin2}, c.currency)
+	if err != nil {
+		c.logger.Debug("price lookup failed", "coins", []string{req.Coin1, req.Coin2}, "error", err)
+	}
+	if p1, p2 := prices[req.Coin1].Price, prices[req.Coin2].Price; p1 > 0 && p2 > 0 {
+		return math.Ceil(req.ReceiveAmount*p2/p1/amountStep) * amountStep
+	}
+	return req.ReceiveAmount
+}
+
+// searchReverse finds the send amount each exchange needs, starting from the
+// estimates at the first guess. Exchanges that never reach the receive
+// amount are left out.
+func (c *Client) searchReverse(ctx context.Context, req EstimateRequest, guess float64, first []Estimate) ([]Estimate, error) {
+	target := req.ReceiveAmount
+	searches := map[string]*reverseSearch{}
+	var order []string
+	record := func(send float64, results []Estimate) {
+		for _, est := range results {
+			s, ok := searches[est.ExchangeName]
+			if !ok {
+				s = &reverseSearch{}
+				searches[est.ExchangeName] = s
+				order = append(order, est.ExchangeName)
+			}
+			s.record(send, est, target)
+		}
+	}
+	record(guess, first)
+
+	for round := range reverseRounds {
+		var amounts []float64
+		for _, name := range order {
+			s := searches[name]
+			if s.done(target) {
+				continue
+			}
+			if x := s.next(target); !slices.Contains(amounts, x) {
+				amounts = append(amounts, x)
+			}
+		}
+		if len(amounts) == 0 {
+			break
+		}
+		c.logger.Debug("reverse estimate", "round", round+1, "probes", len(amounts))
+
+		results, errs := c.probe(ctx, req, amounts)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		failed := 0
+		for i, amount := range amounts {
+			if errs[i] != nil {
+				// A probe outside one exchange's limits can fail while
+				// the others still narrow the search.
+				c.logger.Debug("reverse estimate probe failed", "amount", amount, "error", errs[i])
+				failed++
+				continue
+			}
+			record(amount, results[i])
+		}
+		if failed == len(amounts) {
+			return nil, errs[0]
+		}
+	}
+
+	var estimates []Estimate
+	for _, name := range order {
+		s := searches[name]
+		if s.hi == nil {
+			c.logger.Debug("exchange cannot pay out the receive amount", "exchange", name)
+			continue
+		}
+		est := s.hi.est
+		est.SendAmount = s.hi.send
+		estimates = append(estimates, est)
+	}
+	return estimates, nil
+}
+
+// probe fetches estimates at each amount, a few at a time.
+func (c *Client) probe(ctx context.Context, req EstimateRequest, amounts []float64) ([][]Estimate, []error) {
+	results := make([][]Estimate, len(amounts))
+	errs := make([]error, len(amounts))
+	sem := make(chan struct{}, reverseParallel)
+
+	var wg sync.WaitGroup
+	for i, amount := range amounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// The receive amount is only sent with the first request, to
+			// ask the backend for a native reverse quote.
+			forward := req
+			forward.ReceiveAmount = 0
+			results[i], errs[i] = c.fetchEstimates(ctx, forward, amount)
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
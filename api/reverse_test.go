@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// affineExchange pays out (amount - fee) * rate, minus a network fee, above a
+// minimum deposit.
+type affineExchange struct {
+	name              string
+	rate, fee, netFee float64
+	min               float64
+}
+
+func (e affineExchange) payout(amount float64) float64 {
+	if amount < e.min {
+		return 0
+	}
+	return max((amount-e.fee)*e.rate-e.netFee, 0)
+}
+
+func newEstimateServer(t *testing.T, native bool, exchanges ...affineExchange) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/estimate" {
+			// Price lookups: no prices known.
+			w.Write([]byte(`{}`))
+			return
+		}
+		requests.Add(1)
+		amount, err := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+		if err != nil {
+			t.Errorf("Bad amount: %v", err)
+		}
+		receive, _ := strconv.ParseFloat(r.URL.Query().Get("receive_amount"), 64)
+
+		var results []map[string]any
+		for _, e := range exchanges {
+			result := map[string]any{"Exchange": e.name, "Amount": e.payout(amount), "MinAmount": e.min}
+			if native && receive > 0 {
+				result["Amount"] = receive
+				result["SendAmount"] = (receive+e.netFee)/e.rate + e.fee
+			}
+			results = append(results, result)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"rates": map[string]any{"Results": results}})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClient_ReverseEstimateSearch(t *testing.T) {
+	exchanges := []affineExchange{
+		{name: "Cheap", rate: 160, fee: 0.0005, netFee: 0.01, min: 0.001},
+		{name: "Pricey", rate: 150, fee: 0.001, netFee: 0.02, min: 0.002},
+		{name: "Large", rate: 170, fee: 0, netFee: 0, min: 0.5},
+	}
+	server, requests := newEstimateServer(t, false, exchanges...)
+	client := NewClient(WithBaseURL(server.URL), WithPriceService(NewPriceServiceWithURL(server.URL)))
+
+	const target = 2.5
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", ReceiveAmount: target})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	if len(estimates) != 3 {
+		t.Fatalf("Expected an estimate per exchange, got %+v", estimates)
+	}
+	for i, est := range estimates {
+		if i > 0 && est.SendAmount < estimates[i-1].SendAmount {
+			t.Errorf("Expected estimates sorted by send amount, got %+v", estimates)
+		}
+		var ex affineExchange
+		for _, e := range exchanges {
+			if e.name == est.ExchangeName {
+				ex = e
+			}
+		}
+		if ex.min >= est.SendAmount {
+			// Even the minimum deposit pays out more than the target.
+			continue
+		}
+		if got := ex.payout(est.SendAmount); got < target || got > target*(1+reverseTolerance) {
+			t.Errorf("%s: sending %f pays out %f, want %f within tolerance", est.ExchangeName, est.SendAmount, got, target)
+		}
+		if est.ReceiveAmount < target {
+			t.Errorf("%s: expected receive amount of at least %f, got %f", est.ExchangeName, target, est.ReceiveAmount)
+		}
+	}
+	if last := estimates[len(estimates)-1]; last.ExchangeName != "Large" || last.SendAmount != 0.5 {
+		t.Errorf("Expected Large to need its minimum deposit, got %+v", last)
+	}
+	if n := requests.Load(); n > 12 {
+		t.Errorf("Expected the search to converge quickly, took %d requests", n)
+	}
+}
+
+func TestClient_ReverseEstimateNative(t *testing.T) {
+	server, requests := newEstimateServer(t, true, affineExchange{name: "Native", rate: 160, fee: 0.0005, netFee: 0.01})
+	client := NewClient(WithBaseURL(server.URL), WithPriceService(NewPriceServiceWithURL(server.URL)))
+
+	estimates, err := client.Estimate(context.Background(), EstimateRequest{Coin1: "btc", Coin2: "xmr", ReceiveAmount: 2})
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected a single request to a backend that quotes in reverse, got %d", n)
+	}
+	want := (2+0.01)/160 + 0.0005
+	if len(estimates) != 1 || math.Abs(estimates[0].SendAmount-want) > 1e-9 {
+		t.Errorf("Expected send amount %f from the backend, got %+v", want, estimates)
+	}
+}
+
+func TestReverseSearch_Next(t *testing.T) {
+	// Below the minimum, the amount grows towards it.
+	s := &reverseSearch{lo: &reversePoint{send: 0.001, est: Estimate{MinAmount: 0.01}}}
+	if got := s.next(1); got != 0.01 {
+		t.Errorf("Expected a probe at the minimum, got %f", got)
+	}
+
+	// Between brackets, interpolation stays strictly inside them.
+	s = &reverseSearch{
+		lo: &reversePoint{send: 1, est: Estimate{ReceiveAmount: 1}},
+		hi: &reversePoint{send: 3, est: Estimate{ReceiveAmount: 3}},
+	}
+	if got := s.next(2); got <= 1 || got >= 3 || math.Abs(got-2) > 0.01 {
+		t.Errorf("Expected a probe near 2, got %f", got)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TxStatus is the normalized status of a transaction. Providers report their
+// own vocabulary, which ParseTxStatus maps onto these values.
+type TxStatus string
+
+const (
+	StatusWaiting    TxStatus = "waiting"
+	StatusConfirming TxStatus = "confirming"
+	StatusExchanging TxStatus = "exchanging"
+	StatusSending    TxStatus = "sending"
+	StatusFinished   TxStatus = "finished"
+	StatusFailed     TxStatus = "failed"
+	StatusRefunded   TxStatus = "refunded"
+	StatusExpired    TxStatus = "expired"
+)
+
+// statusVocabulary maps the raw status strings used by the partnered
+// exchanges (ChangeNow, SimpleSwap, StealthEX, FixedFloat, Exolix, Godex,
+// LetsExchange, Trocador and others) onto TxStatus.
+var statusVocabulary = map[string]TxStatus{
+	// Waiting for the user's deposit.
+	"new":     StatusWaiting,
+	"wait":    StatusWaiting,
+	"waiting": StatusWaiting,
+	"pending": StatusWaiting,
+
+	// Deposit seen, waiting for confirmations.
+	"confirming":     StatusConfirming,
+	"confirmation":   StatusConfirming,
+	"confirmed":      StatusConfirming,
+	"verifying":      StatusConfirming,
+	"paid partially": StatusConfirming,
+
+	// Provider is converting the funds.
+	"exchanging": StatusExchanging,
+	"exchange":   StatusExchanging,
+	"hold":       StatusExchanging,
+
+	// Payout broadcast to the receiving address.
+	"sending":  StatusSending,
+	"withdraw": StatusSending,
+
+	"finished": StatusFinished,
+	"success":  StatusFinished,
+	"done":     StatusFinished,
+	"complete": StatusFinished,
+
+	"failed":    StatusFailed,
+	"fail":      StatusFailed,
+	"error":     StatusFailed,
+	"emergency": StatusFailed,
+	"halted":    StatusFailed,
+
+	"refunded": StatusRefunded,
+	"refund":   StatusRefunded,
+
+	"expired": StatusExpired,
+	"overdue": StatusExpired,
+}
+
+// ParseTxStatus maps a provider status string onto a TxStatus. Matching is
+// case-insensitive and ignores surrounding whitespace.
+func ParseTxStatus(raw string) (TxStatus, error) {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	if key == "" {
+		return "", fmt.Errorf("transaction status is empty")
+	}
+	status, ok := statusVocabulary[key]
+	if !ok {
+		return "", fmt.Errorf("unknown transaction status: %q", raw)
+	}
+	return status, nil
+}
+
+// Terminal reports whether a transaction in this status will not change
+// any more.
+func (s TxStatus) Terminal() bool {
+	switch s {
+	case StatusFinished, StatusFailed, StatusRefunded, StatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// Successful reports whether the swap completed and the funds were sent.
+func (s TxStatus) Successful() bool {
+	return s == StatusFinished
+}
+
+func (s TxStatus) String() string {
+	return string(s)
+}
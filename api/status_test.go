@@ -0,0 +1,47 @@
+package api
+
+import "testing"
+
+func TestParseTxStatus(t *testing.T) {
+	testCases := []struct {
+		raw     string
+		want    TxStatus
+		wantErr bool
+	}{
+		{"waiting", StatusWaiting, false},
+		{"  Finished ", StatusFinished, false},
+		{"EXCHANGE", StatusExchanging, false},
+		{"", "", true},
+		{"teleporting", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseTxStatus(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseTxStatus(%q): expected error %v, got %v", tc.raw, tc.wantErr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseTxStatus(%q): expected %s, got %s", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestTxStatus_Terminal(t *testing.T) {
+	terminal := map[TxStatus]bool{
+		StatusWaiting:    false,
+		StatusConfirming: false,
+		StatusExchanging: false,
+		StatusSending:    false,
+		StatusFinished:   true,
+		StatusFailed:     true,
+		StatusRefunded:   true,
+		StatusExpired:    true,
+	}
+
+	for status, want := range terminal {
+		if status.Terminal() != want {
+			t.Errorf("%s.Terminal(): expected %v, got %v", status, want, status.Terminal())
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/pricecache"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the price cache",
+	Long: `Cache inspects and clears the prices kept on disk between runs.
+
+Only CoinGecko prices are cached. Prices from the other price_sources
+(coinpaprika, kraken and file) are looked up afresh on every run.
+
+Prices are only cached on disk when price_cache is set:
+
+  cyphergoat config set price_cache true
+
+Cached prices are used for price_cache_ttl (5m by default), and for
+price_cache_stale after that while they are looked up again.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached price",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		store, prices, err := openPriceCache()
+		if err != nil {
+			return err
+		}
+		cleared := prices.CacheSize()
+		if err := prices.ClearCache(); err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			return printDocument(map[string]any{"path": store.Path(), "cleared": cleared})
+		}
+		fmt.Fprintln(uiWriter(), successStyle(fmt.Sprintf("Cleared %d cached prices from %s", cleared, store.Path())))
+		return nil
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how many prices are cached and how old they are",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		store, prices, err := openPriceCache()
+		if err != nil {
+			return err
+		}
+		doc := newCacheStatsDocument(store, prices)
+
+		if machineOutput() {
+			return printDocument(doc)
+		}
+
+		out := uiWriter()
+		table := newDetailsTable(out)
+		table.Append([]string{keyStyle("Path:"), doc.Path})
+		table.Append([]string{keyStyle("Enabled:"), fmt.Sprintf("%t", doc.Enabled)})
+		table.Append([]string{keyStyle("Size:"), fmt.Sprintf("%d bytes", doc.SizeBytes)})
+		table.Append([]string{keyStyle("Prices:"), fmt.Sprintf("%d", doc.Entries)})
+		table.Append([]string{keyStyle("Fresh:"), fmt.Sprintf("%d", doc.Fresh)})
+		table.Append([]string{keyStyle("Stale:"), fmt.Sprintf("%d", doc.Stale)})
+		table.Append([]string{keyStyle("Expired:"), fmt.Sprintf("%d", doc.Expired)})
+		if doc.Entries > 0 {
+			table.Append([]string{keyStyle("Oldest:"), doc.Oldest.Local().Format(time.DateTime)})
+			table.Append([]string{keyStyle("Newest:"), doc.Newest.Local().Format(time.DateTime)})
+		}
+		table.Render()
+		if !doc.Enabled {
+			fmt.Fprintln(out, infoStyle("The price cache is off. Turn it on with: cyphergoat config set price_cache true"))
+		}
+		return nil
+	},
+}
+
+// openPriceCache returns the on-disk price cache and a price service backed
+// by it, with the configured TTLs.
+func openPriceCache() (*pricecache.Store, *api.PriceService, error) {
+	store, err := pricecache.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	prices := api.NewPriceService()
+	prices.SetCacheTTL(time.Duration(cfg.PriceCacheTTL), time.Duration(cfg.PriceCacheStale))
+	prices.SetStore(store)
+	return store, prices, nil
+}
+
+// cacheStatsDocument is the machine-readable form of the price cache stats.
+type cacheStatsDocument struct {
+	Path      string    `json:"path" yaml:"path"`
+	Enabled   bool      `json:"enabled" yaml:"enabled"`
+	SizeBytes int64     `json:"size_bytes" yaml:"size_bytes"`
+	Entries   int       `json:"entries" yaml:"entries"`
+	Fresh     int       `json:"fresh" yaml:"fresh"`
+	Stale     int       `json:"stale" yaml:"stale"`
+	Expired   int       `json:"expired" yaml:"expired"`
+	Oldest    time.Time `json:"oldest,omitzero" yaml:"oldest,omitempty"`
+	Newest    time.Time `json:"newest,omitzero" yaml:"newest,omitempty"`
+}
+
+func newCacheStatsDocument(store *pricecache.Store, prices *api.PriceService) cacheStatsDocument {
+	stats := prices.CacheStats()
+	doc := cacheStatsDocument{
+		Path:    store.Path(),
+		Enabled: cfg.PriceCache,
+		Entries: prices.CacheSize(),
+		Fresh:   stats.Fresh,
+		Stale:   stats.Stale,
+		Expired: stats.Expired,
+		Oldest:  stats.Oldest.UTC(),
+		Newest:  stats.Newest.UTC(),
+	}
+	if info, err := os.Stat(store.Path()); err == nil {
+		doc.SizeBytes = info.Size()
+	}
+	return doc
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd, cacheStatsCmd)
+}
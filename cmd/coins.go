@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moralpriest/cyphergoat-cli/coins"
+
+	"github.com/spf13/cobra"
+)
+
+var coinsUpdateURL string
+
+var coinsCmd = &cobra.Command{
+	Use:   "coins",
+	Short: "List the known coins and update the coin registry",
+	Long: `Coins shows the coin registry: the name, CoinGecko and CoinPaprika IDs,
+networks, decimals, address format, memo requirement and stablecoin peg of
+every coin the CLI knows about. Prices are looked up by the IDs listed here.
+
+The registry is built in. "coins update" downloads the latest copy, and
+entries in coins.json next to the configuration file replace both, e.g.:
+
+  {"version": 1, "coins": [{"ticker": "xno", "name": "Nano",
+    "coingecko_id": "nano", "networks": ["xno"], "decimals": 30}]}`,
+}
+
+var coinsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the coins in the registry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list := coins.Default().Coins()
+		if machineOutput() {
+			return printDocument(newCoinDocuments(list))
+		}
+
+		table := newTable(uiWriter(), []string{"Ticker", "Name", "Networks", "Decimals", "CoinGecko ID", "Memo", "Peg"})
+		for _, c := range list {
+			memo := ""
+			if c.Memo {
+				memo = "required"
+			}
+			table.Append([]string{
+				strings.ToUpper(c.Ticker),
+				c.Name,
+				strings.Join(c.Networks, ", "),
+				fmt.Sprintf("%d", c.Decimals),
+				c.CoinGeckoID,
+				memo,
+				strings.ToUpper(c.Peg),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var coinsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download the latest coin registry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		httpClient, err := newHTTPClient()
+		if err != nil {
+			return err
+		}
+		path, err := coins.UpdatePath()
+		if err != nil {
+			return err
+		}
+
+		stop := startSpinner(" Downloading the coin registry...")
+		registry, err := coins.Update(cmd.Context(), httpClient, coinsUpdateURL, path)
+		stop()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(uiWriter(), successStyle(fmt.Sprintf("Updated the coin registry: %d coins saved to %s", registry.Len(), path)))
+		return nil
+	},
+}
+
+// coinDocument is the machine-readable form of a coins.Coin.
+type coinDocument struct {
+	Ticker        string   `json:"ticker" yaml:"ticker"`
+	Name          string   `json:"name" yaml:"name"`
+	CoinGeckoID   string   `json:"coingecko_id" yaml:"coingecko_id"`
+	CoinPaprikaID string   `json:"coinpaprika_id" yaml:"coinpaprika_id"`
+	Networks      []string `json:"networks" yaml:"networks"`
+	Decimals      int      `json:"decimals" yaml:"decimals"`
+	AddressFormat string   `json:"address_format" yaml:"address_format"`
+	Memo          bool     `json:"memo" yaml:"memo"`
+	Peg           string   `json:"peg" yaml:"peg"`
+}
+
+func newCoinDocuments(list []coins.Coin) []coinDocument {
+	docs := make([]coinDocument, 0, len(list))
+	for _, c := range list {
+		docs = append(docs, coinDocument(c))
+	}
+	return docs
+}
+
+func init() {
+	rootCmd.AddCommand(coinsCmd)
+	coinsCmd.AddCommand(coinsListCmd, coinsUpdateCmd)
+
+	coinsUpdateCmd.Flags().StringVar(&coinsUpdateURL, "url", coins.DefaultUpdateURL, "URL to download the registry from")
+}
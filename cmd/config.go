@@ -0,0 +1,394 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/coins"
+	"github.com/moralpriest/cyphergoat-cli/config"
+	"github.com/moralpriest/cyphergoat-cli/pricecache"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configPath  string
+	profileName string
+	timeoutFlag time.Duration
+	retriesFlag int
+	proxyFlag   string
+	torFlag     bool
+	fiatFlag    string
+)
+
+// cfg holds the effective settings for this invocation. It is resolved in
+// the root command's PersistentPreRunE.
+var cfg *config.Resolved
+
+// backend is the swap backend commands talk to. Unless a test has already
+// set it (for example to an apitest.Fake), it is the CypherGoat API client for
+// the effective settings, built alongside cfg.
+var backend api.SwapProvider
+
+// skipConfigAnnotation marks commands that must run even when the config file
+// cannot be resolved, so a broken file can still be inspected and repaired.
+const skipConfigAnnotation = "cyphergoat/skip-config"
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration file",
+	Long: `Config reads and writes the configuration file.
+
+Settings are resolved in this order, highest first:
+
+  flags > environment variables > profile > top-level settings > defaults
+
+Named profiles live under "profiles" in the file and are selected with
+--profile or CYPHERGOAT_PROFILE. With --profile, "config set" writes into
+that profile instead of the top level.`,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:         "path",
+	Short:       "Print the configuration file location",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipConfigAnnotation: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the effective settings and where they come from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type setting struct {
+			Key    string `json:"key" yaml:"key"`
+			Value  string `json:"value" yaml:"value"`
+			Source string `json:"source" yaml:"source"`
+		}
+
+		var names []string
+		for _, k := range config.Keys() {
+			if !strings.HasSuffix(k.Name, "<coin>") {
+				names = append(names, k.Name)
+			}
+		}
+		for coin := range cfg.Networks {
+			names = append(names, "networks."+coin)
+		}
+		slices.Sort(names)
+
+		settings := make([]setting, 0, len(names))
+		for _, name := range names {
+			value, err := cfg.Get(name)
+			if err != nil {
+				return err
+			}
+			if name == config.KeyAPIKey {
+				value = maskSecret(value)
+			}
+			settings = append(settings, setting{Key: name, Value: value, Source: string(cfg.Sources[name])})
+		}
+
+		if machineOutput() {
+			return printDocument(settings)
+		}
+
+		out := uiWriter()
+		if cfg.Profile != "" {
+			fmt.Fprintln(out, keyStyle("Profile:"), cfg.Profile)
+		}
+		table := newTable(out, []string{"Key", "Value", "Source"})
+		for _, s := range settings {
+			table.Append([]string{s.Key, s.Value, s.Source})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// configShowSecret makes "config get" print the API key in full.
+var configShowSecret bool
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Long: `Get prints the effective value of a setting. The API key is masked, as in
+"config list", unless --show-secret is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			return err
+		}
+		if args[0] == config.KeyAPIKey && !configShowSecret {
+			value = maskSecret(value)
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a setting to the configuration file",
+	Long: `Set writes a setting to the configuration file. An empty value removes it.
+
+Keys:
+` + configKeysHelp(),
+	Args:        cobra.ExactArgs(2),
+	Annotations: map[string]string{skipConfigAnnotation: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		path, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+
+		file, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+
+		if err := file.SetValue(profileName, args[0], args[1]); err != nil {
+			return err
+		}
+
+		if err := file.Save(path); err != nil {
+			return err
+		}
+
+		target := "top level"
+		if profileName != "" {
+			target = "profile " + profileName
+		}
+		fmt.Fprintf(uiWriter(), "Set %s in %s (%s)\n", args[0], path, target)
+		return nil
+	},
+}
+
+func configKeysHelp() string {
+	var b strings.Builder
+	for _, k := range config.Keys() {
+		fmt.Fprintf(&b, "  %-22s %s", k.Name, k.Help)
+		if k.Env != "" {
+			fmt.Fprintf(&b, " [%s]", k.Env)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func resolveConfigPath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	return config.DefaultPath()
+}
+
+// loadConfig resolves the effective settings, loads the coin registry and
+// builds backend from them.
+func loadConfig(cmd *cobra.Command) error {
+	path, err := resolveConfigPath()
+	if err != nil {
+		return err
+	}
+
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := file.Resolve(profileName, os.Getenv)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if cmd.Flags().Changed("timeout") {
+		if err := resolved.Override(config.KeyTimeout, timeoutFlag.String()); err != nil {
+			return fmt.Errorf("invalid --timeout: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("retries") {
+		if err := resolved.Override(config.KeyRetries, strconv.Itoa(retriesFlag)); err != nil {
+			return fmt.Errorf("invalid --retries: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed("proxy") {
+		if err := resolved.Override(config.KeyProxy, proxyFlag); err != nil {
+			return fmt.Errorf("invalid --proxy: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("tor") {
+		if err := resolved.Override(config.KeyTor, strconv.FormatBool(torFlag)); err != nil {
+			return fmt.Errorf("invalid --tor: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("fiat") {
+		if err := resolved.Override(config.KeyFiat, fiatFlag); err != nil {
+			return fmt.Errorf("invalid --fiat: %w", err)
+		}
+	}
+
+	cfg = resolved
+
+	registry, err := coins.Load()
+	if err != nil {
+		return err
+	}
+	coins.SetDefault(registry)
+
+	if backend != nil {
+		return nil
+	}
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	backend = client
+	return nil
+}
+
+// newAPIClient builds the API client for the effective settings.
+func newAPIClient() (*api.Client, error) {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	retry := api.DefaultRetryPolicy()
+	if cfg.Retries != nil {
+		retry.Retries = *cfg.Retries
+	}
+	retry.Budget = time.Duration(cfg.RetryBudget)
+
+	opts := []api.Option{
+		api.WithAPIKey(cfg.APIKey),
+		api.WithHTTPClient(httpClient),
+		api.WithUserAgent("cyphergoat-cli/" + version),
+		api.WithRetryPolicy(retry),
+		api.WithCurrency(cfg.Fiat),
+	}
+	if cfg.APIURL != "" {
+		opts = append(opts, api.WithBaseURL(cfg.APIURL))
+	}
+	if verbose {
+		handler := slog.NewTextHandler(uiWriter(), &slog.HandlerOptions{Level: slog.LevelDebug})
+		opts = append(opts, api.WithLogger(slog.New(handler)))
+	}
+	prices, err := newPriceSource(httpClient, retry)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, api.WithPriceSource(prices))
+
+	return api.NewClient(opts...), nil
+}
+
+// newPriceSource chains the price sources named in price_sources, or returns
+// CoinGecko alone if there are none. They share the HTTP client, so the proxy
+// applies to them too.
+func newPriceSource(httpClient *http.Client, retry api.RetryPolicy) (api.PriceSource, error) {
+	if len(cfg.PriceSources) == 0 {
+		return newPriceService(httpClient, retry)
+	}
+
+	sources := make([]api.PriceSource, 0, len(cfg.PriceSources))
+	for _, name := range cfg.PriceSources {
+		switch name {
+		case "coingecko":
+			coingecko, err := newPriceService(httpClient, retry)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, coingecko)
+		case "coinpaprika":
+			coinpaprika := api.NewCoinPaprikaSource(httpClient)
+			coinpaprika.SetRetryPolicy(retry)
+			sources = append(sources, coinpaprika)
+		case "kraken":
+			kraken := api.NewKrakenSource(httpClient)
+			kraken.SetRetryPolicy(retry)
+			sources = append(sources, kraken)
+		case "file":
+			if cfg.PriceFile == "" {
+				return nil, fmt.Errorf("price source \"file\" needs %s to be set", config.KeyPriceFile)
+			}
+			file, err := api.LoadStaticPriceSource(cfg.PriceFile)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, file)
+		default:
+			return nil, fmt.Errorf("unknown price source %q", name)
+		}
+	}
+	chain := api.NewPriceChain(sources...)
+	chain.SetMedian(cfg.PriceMedian)
+	return chain, nil
+}
+
+// newPriceService returns the CoinGecko price service, caching prices on disk
+// when price_cache is set.
+func newPriceService(httpClient *http.Client, retry api.RetryPolicy) (*api.PriceService, error) {
+	prices := api.NewPriceService()
+	prices.SetHTTPClient(httpClient)
+	prices.SetRetryPolicy(retry)
+	prices.SetCacheTTL(time.Duration(cfg.PriceCacheTTL), time.Duration(cfg.PriceCacheStale))
+	if cfg.PriceCache {
+		store, err := pricecache.Open()
+		if err != nil {
+			return nil, err
+		}
+		prices.SetStore(store)
+	}
+	priceServices = append(priceServices, prices)
+	return prices, nil
+}
+
+// priceServices are the price services built for this run, so that their
+// background refreshes can finish before the process exits.
+var priceServices []*api.PriceService
+
+// waitForPrices waits for the background refreshes of every price service.
+func waitForPrices() {
+	for _, prices := range priceServices {
+		prices.Wait()
+	}
+}
+
+// maskSecret hides all but the first few characters of a secret.
+func maskSecret(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:4] + strings.Repeat("*", len(s)-4)
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPathCmd, configListCmd, configGetCmd, configSetCmd)
+
+	configGetCmd.Flags().BoolVar(&configShowSecret, "show-secret", false, "Print the API key in full")
+}
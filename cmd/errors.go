@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+)
+
+// printAPIKeyHelp explains how to configure the API key.
+func printAPIKeyHelp(out io.Writer) {
+	fmt.Fprintln(out, infoStyle("To set your API key, run one of the following:"))
+	fmt.Fprintln(out, "  export CYPHERGOAT_API_KEY=\"your_api_key_here\"")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, infoStyle("Or add it to your shell config file:"))
+	fmt.Fprintln(out, "  set -gx CYPHERGOAT_API_KEY \"your_api_key_here\"  # for fish shell")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, infoStyle("Or save it in the config file:"))
+	fmt.Fprintln(out, "  cyphergoat config set api_key \"your_api_key_here\"")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, infoStyle("Get your API key from: https://cyphergoat.com"))
+}
+
+// printErrorHint follows an API failure with advice on what to do about it.
+// Errors without a known cause get no advice.
+func printErrorHint(out io.Writer, err error) {
+	switch {
+	case errors.Is(err, api.ErrUnauthorized):
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, infoStyle("The API key was rejected. Check that it is set correctly."))
+		fmt.Fprintln(out)
+		printAPIKeyHelp(out)
+	case errors.Is(err, api.ErrRateLimited):
+		wait := "a moment"
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter.Round(time.Second).String()
+		}
+		fmt.Fprintln(out, infoStyle(fmt.Sprintf("Too many requests. Wait %s and try again.", wait)))
+	case errors.Is(err, api.ErrPairUnsupported):
+		fmt.Fprintln(out, infoStyle("No exchange supports this pair. Check the coin tickers and networks (--from-network, --to-network)."))
+	case errors.Is(err, api.ErrAmountBelowMinimum):
+		fmt.Fprintln(out, infoStyle("The amount is below the exchange minimum. Try a larger --amount."))
+	case errors.Is(err, api.ErrInvalidAddress):
+		fmt.Fprintln(out, infoStyle("The address was rejected. Check that it belongs to the coin and network you are receiving."))
+	case errors.Is(err, api.ErrQuoteExpired):
+		fmt.Fprintln(out, infoStyle("The fixed-rate quote expired before the trade was created. Run the swap again for a fresh quote."))
+	case errors.Is(err, api.ErrUnavailable):
+		fmt.Fprintln(out, infoStyle("The CypherGoat API is having problems. Try again in a few minutes."))
+	}
+
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+		fmt.Fprintln(out, infoStyle("Include request ID "+apiErr.RequestID+" if you contact support."))
+	}
+}
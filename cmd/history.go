@@ -0,0 +1,263 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/history"
+
+	"github.com/spf13/cobra"
+)
+
+var historyOpts struct {
+	coin     string
+	provider string
+	status   string
+	since    string
+	until    string
+	limit    int
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past trades",
+	Long: `History lists the trades created with this CLI, newest first.
+
+Every trade created by swap is saved locally, including the deposit address,
+transaction ID and CGID, so they are not lost when the terminal closes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		filter, err := historyFilter()
+		if err != nil {
+			return err
+		}
+
+		store, err := openHistory()
+		if err != nil {
+			return err
+		}
+
+		records, err := store.List(filter)
+		if err != nil {
+			return err
+		}
+		if historyOpts.limit > 0 && len(records) > historyOpts.limit {
+			records = records[:historyOpts.limit]
+		}
+
+		if machineOutput() {
+			return printDocument(newHistoryDocuments(records))
+		}
+
+		out := uiWriter()
+		if len(records) == 0 {
+			fmt.Fprintln(out, infoStyle("No trades found."))
+			return nil
+		}
+
+		table := newTable(out, []string{"Date", "Transaction ID", "Pair", "Amount", "Provider", "Status"})
+		for _, r := range records {
+			tx := r.Transaction
+			table.Append([]string{
+				r.SavedAt.Local().Format(time.DateTime),
+				tx.Id,
+				fmt.Sprintf("%s -> %s", strings.ToUpper(tx.Coin1), strings.ToUpper(tx.Coin2)),
+				fmt.Sprintf("%.8f %s", tx.SendAmount, strings.ToUpper(tx.Coin1)),
+				tx.Provider,
+				displayStatus(tx.Status),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id|cgid>",
+	Short: "Show a past trade",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		store, err := openHistory()
+		if err != nil {
+			return err
+		}
+
+		record, err := store.Get(args[0])
+		if errors.Is(err, history.ErrNotFound) {
+			return fmt.Errorf("no trade with ID %q in %s", args[0], store.Path())
+		}
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			return printDocument(newHistoryDocuments([]history.Record{record})[0])
+		}
+
+		tx := record.Transaction
+		out := uiWriter()
+		detailsTable := newDetailsTable(out)
+		detailsTable.Append([]string{keyStyle("Created:"), record.SavedAt.Local().Format(time.DateTime)})
+		detailsTable.Append([]string{keyStyle("Transaction ID:"), tx.Id})
+		detailsTable.Append([]string{keyStyle("Exchange Provider:"), tx.Provider})
+		if tx.RateType != "" {
+			detailsTable.Append([]string{keyStyle("Rate Type:"), displayRateType(tx.RateType)})
+		}
+		if tx.KYC != "" {
+			detailsTable.Append([]string{keyStyle("KYC Rating:"), displayKYC(tx.KYC)})
+		}
+		detailsTable.Append([]string{keyStyle("Amount to Send:"), fmt.Sprintf("%.8f %s", tx.SendAmount, strings.ToUpper(tx.Coin1))})
+		detailsTable.Append([]string{keyStyle("Estimated Receive:"), fmt.Sprintf("%.8f %s", tx.EstimateAmount, strings.ToUpper(tx.Coin2))})
+		detailsTable.Append([]string{keyStyle("Deposit Address:"), tx.Address})
+		if record.ReceiveAddress != "" {
+			detailsTable.Append([]string{keyStyle("Receive Address:"), record.ReceiveAddress})
+		}
+		appendMemoDetails(detailsTable, tx)
+		detailsTable.Append([]string{keyStyle("Status:"), displayStatus(tx.Status)})
+		if url := trackURL(tx); url != "" {
+			detailsTable.Append([]string{keyStyle("Track on cyphergoat.com:"), url})
+		}
+		detailsTable.Render()
+		return nil
+	},
+}
+
+// openHistory returns the local trade history, warning about lines of the
+// ledger it has to skip.
+func openHistory() (*history.Store, error) {
+	store, err := history.Open()
+	if err != nil {
+		return nil, err
+	}
+	logger := NewLogger(verbose)
+	store.SetWarn(func(err error) { logger.Warn("%s", err) })
+	return store, nil
+}
+
+// saveTrade records a newly created trade in the local history. Failing to
+// save is reported but does not fail the swap, which already happened.
+func saveTrade(tx api.Transaction, receiveAddress string) {
+	store, err := openHistory()
+	if err == nil {
+		_, err = store.Append(tx, receiveAddress)
+	}
+	if err != nil {
+		fmt.Fprintln(uiWriter(), errorStyle("Warning:"), "could not save trade to history:", err)
+		return
+	}
+	NewLogger(verbose).Debug("Saved trade %s to %s", tx.Id, store.Path())
+}
+
+// updateTrade refreshes a trade in the local history if it was saved there.
+func updateTrade(tx api.Transaction) {
+	logger := NewLogger(verbose)
+
+	store, err := openHistory()
+	if err == nil {
+		_, err = store.Update(tx)
+	}
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		logger.Debug("Trade %s is not in the local history", tx.Id)
+	case err != nil:
+		logger.Debug("Could not update trade history: %s", err)
+	}
+}
+
+// providerReliability scores exchanges by the outcome of the trades in the
+// local history. Without a history every exchange scores the same.
+func providerReliability() map[string]float64 {
+	store, err := openHistory()
+	var records []history.Record
+	if err == nil {
+		records, err = store.List(history.Filter{})
+	}
+	if err != nil {
+		NewLogger(verbose).Debug("Could not read trade history: %s", err)
+		return nil
+	}
+	return history.Reliability(records)
+}
+
+func historyFilter() (history.Filter, error) {
+	filter := history.Filter{
+		Coin:     strings.ToLower(historyOpts.coin),
+		Provider: historyOpts.provider,
+	}
+
+	if historyOpts.status != "" {
+		status, err := api.ParseTxStatus(historyOpts.status)
+		if err != nil {
+			return filter, err
+		}
+		filter.Status = status
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryDate(historyOpts.since); err != nil {
+		return filter, fmt.Errorf("invalid --since: %w", err)
+	}
+	if filter.Until, err = parseHistoryDate(historyOpts.until); err != nil {
+		return filter, fmt.Errorf("invalid --until: %w", err)
+	}
+	if historyOpts.until != "" && len(historyOpts.until) == len(time.DateOnly) {
+		// A bare date includes the whole day.
+		filter.Until = filter.Until.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// parseHistoryDate accepts a date (2006-01-02) in local time or an RFC 3339
+// timestamp.
+func parseHistoryDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// historyDocument is the machine-readable form of a history.Record.
+type historyDocument struct {
+	Transaction    transactionDocument `json:"transaction" yaml:"transaction"`
+	ReceiveAddress string              `json:"receive_address" yaml:"receive_address"`
+	SavedAt        time.Time           `json:"saved_at" yaml:"saved_at"`
+	UpdatedAt      time.Time           `json:"updated_at" yaml:"updated_at"`
+}
+
+func newHistoryDocuments(records []history.Record) []historyDocument {
+	docs := make([]historyDocument, 0, len(records))
+	for _, r := range records {
+		docs = append(docs, historyDocument{
+			Transaction:    newTransactionDocument(r.Transaction),
+			ReceiveAddress: r.ReceiveAddress,
+			SavedAt:        r.SavedAt,
+			UpdatedAt:      r.UpdatedAt,
+		})
+	}
+	return docs
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.Flags().StringVar(&historyOpts.coin, "coin", "", "Only trades sending or receiving this coin")
+	historyCmd.Flags().StringVar(&historyOpts.provider, "provider", "", "Only trades with this exchange provider")
+	historyCmd.Flags().StringVar(&historyOpts.status, "status", "", "Only trades in this status (e.g. waiting, finished, refunded)")
+	historyCmd.Flags().StringVar(&historyOpts.since, "since", "", "Only trades created on or after this date (YYYY-MM-DD or RFC 3339)")
+	historyCmd.Flags().StringVar(&historyOpts.until, "until", "", "Only trades created on or before this date (YYYY-MM-DD or RFC 3339)")
+	historyCmd.Flags().IntVarP(&historyOpts.limit, "limit", "n", 0, "Show at most this many trades")
+}
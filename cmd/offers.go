@@ -0,0 +1,189 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/config"
+
+	"github.com/spf13/cobra"
+)
+
+// offerOptions choose which offers are shown and in what order. They are
+// shared by the commands that fetch estimates.
+type offerOptions struct {
+	maxKYC  string
+	sort    string
+	only    []string
+	exclude []string
+}
+
+// addOfferFlags registers the offer options on cmd.
+func addOfferFlags(cmd *cobra.Command, opts *offerOptions) {
+	cmd.Flags().StringVar(&opts.maxKYC, "max-kyc", "", "Only show exchanges with this KYC rating or better, A (no KYC) to D")
+	cmd.Flags().StringVar(&opts.sort, "sort", "amount", "Order offers by amount, value, kyc, reliability or composite (weighted by rank_weights)")
+	cmd.Flags().StringSliceVar(&opts.only, "only", nil, "Only get quotes from these exchanges (comma-separated)")
+	cmd.Flags().StringSliceVar(&opts.exclude, "exclude", nil, "Never get quotes from these exchanges (comma-separated)")
+}
+
+// apply checks the options and applies them over the config.
+func (o offerOptions) apply() error {
+	if o.maxKYC != "" {
+		if err := cfg.Override(config.KeyMaxKYC, o.maxKYC); err != nil {
+			return fmt.Errorf("invalid --max-kyc: %w", err)
+		}
+	}
+	// --only replaces the allow-list; --exclude adds to the deny-list, so
+	// that exchanges excluded in the config stay excluded.
+	if len(o.only) > 0 {
+		if err := cfg.Override(config.KeyAllowedExchanges, strings.Join(o.only, ",")); err != nil {
+			return err
+		}
+	}
+	if len(o.exclude) > 0 {
+		excluded := append(slices.Clone(cfg.ExcludedExchanges), o.exclude...)
+		if err := cfg.Override(config.KeyExcludedExchanges, strings.Join(excluded, ",")); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(sortOrders, strings.ToLower(o.sort)) {
+		return fmt.Errorf("invalid --sort value %q (supported: %s)", o.sort, strings.Join(sortOrders, ", "))
+	}
+	return nil
+}
+
+// hiddenOffers counts the offers left out by the max_kyc filter.
+type hiddenOffers struct {
+	// worse is the number from exchanges rated worse than max_kyc.
+	worse int
+	// unrated is the number from exchanges without a KYC rating, which
+	// api.FilterKYC drops whenever max_kyc is set.
+	unrated int
+}
+
+func (h hiddenOffers) total() int {
+	return h.worse + h.unrated
+}
+
+// note describes the hidden offers below a table of the ones shown, or
+// returns "" if none are hidden.
+func (h hiddenOffers) note() string {
+	switch {
+	case h.worse > 0 && h.unrated > 0:
+		return fmt.Sprintf("Hidden offers from exchanges rated worse than KYC %s: %d, and from unrated exchanges: %d.", cfg.MaxKYC, h.worse, h.unrated)
+	case h.worse > 0:
+		return fmt.Sprintf("Hidden offers from exchanges rated worse than KYC %s: %d.", cfg.MaxKYC, h.worse)
+	case h.unrated > 0:
+		return fmt.Sprintf("Hidden offers from unrated exchanges: %d.", h.unrated)
+	}
+	return ""
+}
+
+// fetchEstimates quotes a swap behind a spinner and orders the offers with
+// ranker. Offers from exchanges outside the allow and deny lists are left
+// out, which is only reported in verbose mode. Offers from exchanges rated
+// worse than max_kyc, or not rated at all, are left out too and counted.
+func fetchEstimates(ctx context.Context, req api.EstimateRequest, ranker api.Ranker) ([]api.Estimate, hiddenOffers, error) {
+	stopSpinner := startSpinner(" Fetching Rates from Partnered Exchanges...")
+	estimates, err := backend.Estimate(ctx, req)
+	stopSpinner()
+	if err != nil {
+		return nil, hiddenOffers{}, err
+	}
+
+	n := len(estimates)
+	estimates = api.FilterExchanges(estimates, cfg.AllowedExchanges, cfg.ExcludedExchanges)
+	if filtered := n - len(estimates); filtered > 0 {
+		NewLogger(verbose).Debug("Providers left out by the exchange allow and deny lists: %d", filtered)
+	}
+
+	var hidden hiddenOffers
+	if limit := maxKYC(); limit != 0 {
+		for _, est := range estimates {
+			if est.KYCScore < api.KYCBest {
+				hidden.unrated++
+			}
+		}
+		n = len(estimates)
+		estimates = api.FilterKYC(estimates, limit)
+		hidden.worse = n - len(estimates) - hidden.unrated
+	}
+	ranker.Rank(estimates)
+	return estimates, hidden, nil
+}
+
+// sortOrders lists the values --sort accepts.
+var sortOrders = []string{"amount", "value", "kyc", "reliability", "composite"}
+
+// newRanker returns the ranker for a --sort value, which has already been
+// checked against sortOrders. For reverse quotes every offer pays the same,
+// so "amount" ranks by the deposit instead.
+func newRanker(order string, reverse bool) api.Ranker {
+	switch strings.ToLower(order) {
+	case "value":
+		return api.ByValue
+	case "kyc":
+		return api.ByKYC
+	case "reliability":
+		return api.ByReliability(providerReliability())
+	case "composite":
+		return api.Composite(rankWeights(), providerReliability())
+	}
+	if reverse {
+		return api.ByDeposit
+	}
+	return api.ByAmount
+}
+
+// rankWeights returns the rank_weights setting, or api.DefaultWeights.
+func rankWeights() api.Weights {
+	w := cfg.RankWeights
+	if len(w) == 0 {
+		return api.DefaultWeights
+	}
+	return api.Weights{
+		Amount:      w["amount"],
+		Value:       w["value"],
+		KYC:         w["kyc"],
+		Reliability: w["reliability"],
+	}
+}
+
+// printNoOffers explains why no offers are left to show.
+func printNoOffers(out io.Writer, hidden hiddenOffers, fixed bool) {
+	switch {
+	case hidden.total() > 0:
+		fmt.Fprintln(out, errorStyle(fmt.Sprintf("No exchanges rated KYC %s or better offer this trading pair", cfg.MaxKYC)))
+		if hidden.worse > 0 {
+			fmt.Fprintln(out, infoStyle(fmt.Sprintf("Offers left out by the KYC filter: %d. Raise --max-kyc to see them.", hidden.worse)))
+		}
+		if hidden.unrated > 0 {
+			fmt.Fprintln(out, infoStyle(fmt.Sprintf("Offers from unrated exchanges left out: %d. They are only shown without a KYC limit.", hidden.unrated)))
+		}
+	case fixed:
+		fmt.Fprintln(out, errorStyle("No fixed-rate offers available for this trading pair"))
+		fmt.Fprintln(out, infoStyle("Run without --fixed to see floating-rate offers."))
+	default:
+		fmt.Fprintln(out, errorStyle("No exchanges available for this trading pair"))
+		if len(cfg.AllowedExchanges) > 0 || len(cfg.ExcludedExchanges) > 0 {
+			fmt.Fprintln(out, infoStyle("Some exchanges may have been left out by --only, --exclude or the allowed_exchanges and excluded_exchanges settings."))
+		}
+	}
+}
+
+// maxKYC returns the worst KYC score to accept, or 0 for any.
+func maxKYC() int {
+	if cfg.MaxKYC == "" {
+		return 0
+	}
+	// The setting is validated when the config is loaded.
+	score, _ := api.ParseKYC(cfg.MaxKYC)
+	return score
+}
@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/address"
+	"github.com/moralpriest/cyphergoat-cli/api"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormat string
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	default:
+		return fmt.Errorf("invalid --output value %q (supported: table, json, yaml)", outputFormat)
+	}
+}
+
+// machineOutput reports whether results are emitted as JSON or YAML documents
+// rather than human-readable tables.
+func machineOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// uiWriter returns where human-facing text (titles, tables, hints) goes. In
+// machine mode it is moved to stderr so stdout only carries the document.
+func uiWriter() io.Writer {
+	if machineOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// surveyOpts keeps interactive prompts off stdout in machine mode.
+func surveyOpts() []survey.AskOpt {
+	if machineOutput() {
+		return []survey.AskOpt{survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)}
+	}
+	return nil
+}
+
+// startSpinner shows a spinner with the given suffix and returns a function
+// that stops it. No spinner is shown in machine mode.
+func startSpinner(suffix string) func() {
+	if machineOutput() {
+		return func() {}
+	}
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = suffix
+	_ = s.Color("cyan")
+	s.Start()
+	return s.Stop
+}
+
+// newTable returns a borderless table writing to w with a cyan header, or a
+// plain header when colors are disabled.
+func newTable(w io.Writer, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetBorder(false)
+	if !color.NoColor {
+		colors := make([]tablewriter.Colors, len(header))
+		for i := range colors {
+			colors[i] = tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor}
+		}
+		table.SetHeaderColor(colors...)
+	}
+	return table
+}
+
+// newDetailsTable returns a two-column key/value table writing to w.
+func newDetailsTable(w io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAutoWrapText(false)
+	table.SetColumnSeparator(" ")
+	return table
+}
+
+// appendMemoDetails adds the receive memo and refund details of tx, if any,
+// to a details table.
+func appendMemoDetails(table *tablewriter.Table, tx api.Transaction) {
+	if tx.ExtraID != "" {
+		label := "Receive Memo:"
+		if memo, ok := address.MemoFor(tx.Coin2, tx.Network2); ok {
+			words := strings.Fields(memo.Name)
+			for i, w := range words {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+			label = "Receive " + strings.Join(words, " ") + ":"
+		}
+		table.Append([]string{keyStyle(label), tx.ExtraID})
+	}
+	if tx.RefundAddress != "" {
+		table.Append([]string{keyStyle("Refund Address:"), tx.RefundAddress})
+	}
+	if tx.RefundMemo != "" {
+		table.Append([]string{keyStyle("Refund Memo:"), tx.RefundMemo})
+	}
+}
+
+// rateLabel describes an estimate's rate type, with the time left on a
+// fixed-rate quote.
+func rateLabel(est api.Estimate, now time.Time) string {
+	if !est.Fixed() {
+		return "floating"
+	}
+	if est.ExpiresAt.IsZero() {
+		return "fixed"
+	}
+	if est.Expired(now) {
+		return "fixed, expired"
+	}
+	return fmt.Sprintf("fixed, %s left", est.ExpiresAt.Sub(now).Round(time.Second))
+}
+
+// formatLimit formats an exchange's minimum or maximum amount, or "-" when
+// it has none.
+func formatLimit(v float64) string {
+	if v <= 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// bestReceive returns the largest payout among estimates, which spreads are
+// measured against whatever order they are ranked in.
+func bestReceive(estimates []api.Estimate) float64 {
+	var best float64
+	for _, est := range estimates {
+		best = max(best, est.ReceiveAmount)
+	}
+	return best
+}
+
+// spreadPercent returns how much less an estimate pays out than the best
+// offer, in percent.
+func spreadPercent(est api.Estimate, best float64) float64 {
+	if best <= 0 {
+		return 0
+	}
+	return (best - est.ReceiveAmount) / best * 100
+}
+
+// formatSpread formats a spread for estimate tables.
+func formatSpread(spread float64) string {
+	if spread < 0.005 {
+		return "best"
+	}
+	return fmt.Sprintf("-%.2f%%", spread)
+}
+
+// formatValue formats the value of an estimate's payout for tables, or "n/a"
+// when no price source knew the coin's price.
+func formatValue(est api.Estimate) string {
+	if !est.Valued() {
+		return "n/a"
+	}
+	return est.TradeValue.String()
+}
+
+// printKYCLegend explains the KYC ratings shown in estimate tables.
+func printKYCLegend(w io.Writer) {
+	fmt.Fprintln(w, infoStyle("KYC ratings:"))
+	for score := api.KYCBest; score <= api.KYCWorst; score++ {
+		fmt.Fprintf(w, "  %s  %s\n", api.KYCGrade(score), api.KYCDescription(score))
+	}
+}
+
+// displayKYC describes a transaction's KYC rating, which the API sends as a
+// letter or a score.
+func displayKYC(kyc string) string {
+	score, err := api.ParseKYC(kyc)
+	if err != nil {
+		return kyc
+	}
+	return fmt.Sprintf("%s (%s)", api.KYCGrade(score), api.KYCDescription(score))
+}
+
+// displayRateType describes a transaction's rate type for the details table.
+func displayRateType(rt api.RateType) string {
+	switch rt {
+	case api.RateFixed:
+		return "Fixed (guaranteed amount)"
+	case api.RateFloating:
+		return "Floating (amount may change)"
+	}
+	return string(rt)
+}
+
+// printDocument writes v to stdout in the selected machine-readable format.
+func printDocument(v any) error {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(v)
+	default:
+		return fmt.Errorf("output format %q is not a document format", outputFormat)
+	}
+}
+
+// estimateDocument is the machine-readable form of an api.Estimate.
+type estimateDocument struct {
+	Rank          int        `json:"rank" yaml:"rank"`
+	Exchange      string     `json:"exchange" yaml:"exchange"`
+	FromCoin      string     `json:"from_coin" yaml:"from_coin"`
+	FromNetwork   string     `json:"from_network" yaml:"from_network"`
+	ToCoin        string     `json:"to_coin" yaml:"to_coin"`
+	ToNetwork     string     `json:"to_network" yaml:"to_network"`
+	SendAmount    float64    `json:"send_amount" yaml:"send_amount"`
+	ReceiveAmount float64    `json:"receive_amount" yaml:"receive_amount"`
+	Rate          float64    `json:"rate" yaml:"rate"`
+	SpreadPercent float64    `json:"spread_percent" yaml:"spread_percent"`
+	MinAmount     float64    `json:"min_amount" yaml:"min_amount"`
+	MaxAmount     float64    `json:"max_amount,omitempty" yaml:"max_amount,omitempty"`
+	WithinLimits  bool       `json:"within_limits" yaml:"within_limits"`
+	KYCScore      int        `json:"kyc_score" yaml:"kyc_score"`
+	KYCRating     string     `json:"kyc_rating" yaml:"kyc_rating"`
+	Value         *float64   `json:"value" yaml:"value"`
+	Currency      string     `json:"currency" yaml:"currency"`
+	PriceSource   string     `json:"price_source,omitempty" yaml:"price_source,omitempty"`
+	ValueUSD      float64    `json:"value_usd" yaml:"value_usd"`
+	RateType      string     `json:"rate_type" yaml:"rate_type"`
+	RateID        string     `json:"rate_id,omitempty" yaml:"rate_id,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+func newEstimateDocuments(estimates []api.Estimate) []estimateDocument {
+	best := bestReceive(estimates)
+	docs := make([]estimateDocument, 0, len(estimates))
+	for i, est := range estimates {
+		doc := estimateDocument{
+			Rank:          i + 1,
+			Exchange:      est.ExchangeName,
+			FromCoin:      strings.ToLower(est.Coin1),
+			FromNetwork:   strings.ToLower(est.Network1),
+			ToCoin:        strings.ToLower(est.Coin2),
+			ToNetwork:     strings.ToLower(est.Network2),
+			SendAmount:    est.SendAmount,
+			ReceiveAmount: est.ReceiveAmount,
+			Rate:          est.Rate(),
+			SpreadPercent: spreadPercent(est, best),
+			MinAmount:     est.MinAmount,
+			MaxAmount:     est.MaxAmount,
+			WithinLimits:  est.WithinLimits(),
+			KYCScore:      est.KYCScore,
+			KYCRating:     api.KYCGrade(est.KYCScore),
+			Currency:      est.TradeValue.Currency,
+			PriceSource:   est.PriceSource,
+			RateType:      string(est.RateType),
+			RateID:        est.RateID,
+		}
+		// value is null when the price is not known. value_usd predates
+		// the currency setting and is only filled in when values are in USD.
+		if est.Valued() {
+			value := est.TradeValue.Amount
+			doc.Value = &value
+		}
+		if est.Valued() && est.TradeValue.Currency == api.DefaultCurrency {
+			doc.ValueUSD = est.TradeValue.Amount
+		}
+		if !est.ExpiresAt.IsZero() {
+			expiresAt := est.ExpiresAt.UTC()
+			doc.ExpiresAt = &expiresAt
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// transactionDocument is the machine-readable form of an api.Transaction.
+type transactionDocument struct {
+	ID             string     `json:"id" yaml:"id"`
+	CGID           string     `json:"cgid" yaml:"cgid"`
+	Provider       string     `json:"provider" yaml:"provider"`
+	FromCoin       string     `json:"from_coin" yaml:"from_coin"`
+	FromNetwork    string     `json:"from_network" yaml:"from_network"`
+	ToCoin         string     `json:"to_coin" yaml:"to_coin"`
+	ToNetwork      string     `json:"to_network" yaml:"to_network"`
+	SendAmount     float64    `json:"send_amount" yaml:"send_amount"`
+	EstimateAmount float64    `json:"estimate_amount" yaml:"estimate_amount"`
+	DepositAddress string     `json:"deposit_address" yaml:"deposit_address"`
+	ExtraID        string     `json:"extra_id,omitempty" yaml:"extra_id,omitempty"`
+	RefundAddress  string     `json:"refund_address,omitempty" yaml:"refund_address,omitempty"`
+	RefundMemo     string     `json:"refund_memo,omitempty" yaml:"refund_memo,omitempty"`
+	RateType       string     `json:"rate_type,omitempty" yaml:"rate_type,omitempty"`
+	Status         string     `json:"status" yaml:"status"`
+	Done           bool       `json:"done" yaml:"done"`
+	KYC            string     `json:"kyc" yaml:"kyc"`
+	TrackURL       string     `json:"track_url" yaml:"track_url"`
+	CreatedAt      *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+}
+
+func newTransactionDocument(tx api.Transaction) transactionDocument {
+	doc := transactionDocument{
+		ID:             tx.Id,
+		CGID:           tx.CGID,
+		Provider:       tx.Provider,
+		FromCoin:       strings.ToLower(tx.Coin1),
+		FromNetwork:    strings.ToLower(tx.Network1),
+		ToCoin:         strings.ToLower(tx.Coin2),
+		ToNetwork:      strings.ToLower(tx.Network2),
+		SendAmount:     tx.SendAmount,
+		EstimateAmount: tx.EstimateAmount,
+		DepositAddress: tx.Address,
+		ExtraID:        tx.ExtraID,
+		RefundAddress:  tx.RefundAddress,
+		RefundMemo:     tx.RefundMemo,
+		RateType:       string(tx.RateType),
+		Status:         string(tx.Status),
+		Done:           tx.Done,
+		KYC:            tx.KYC,
+		TrackURL:       trackURL(tx),
+	}
+	if !tx.CreatedAt.IsZero() {
+		createdAt := tx.CreatedAt.UTC()
+		doc.CreatedAt = &createdAt
+	}
+	return doc
+}
+
+// trackURL returns the cyphergoat.com page for a transaction.
+func trackURL(tx api.Transaction) string {
+	if tx.CGID == "" {
+		return ""
+	}
+	return "https://cyphergoat.com/transaction/" + tx.CGID
+}
+
+// swapDocument is emitted by the swap command once the trade is created.
+type swapDocument struct {
+	Estimates   []estimateDocument  `json:"estimates" yaml:"estimates"`
+	Selected    estimateDocument    `json:"selected" yaml:"selected"`
+	Transaction transactionDocument `json:"transaction" yaml:"transaction"`
+}
+
+// versionDocument is emitted by the version command.
+type versionDocument struct {
+	Version string `json:"version" yaml:"version"`
+	Commit  string `json:"commit" yaml:"commit"`
+	Date    string `json:"date" yaml:"date"`
+	Go      string `json:"go" yaml:"go"`
+}
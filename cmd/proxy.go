@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// defaultTorProxy is the SOCKS port of a local Tor daemon.
+const defaultTorProxy = "socks5h://127.0.0.1:9050"
+
+// torCheckTimeout bounds the reachability check of the Tor proxy.
+const torCheckTimeout = 5 * time.Second
+
+// newHTTPClient returns the HTTP client for every outbound request, routed
+// through the configured proxy. With Tor enabled, requests fail unless the
+// proxy answers; they never fall back to a direct connection.
+func newHTTPClient() (*http.Client, error) {
+	client := &http.Client{Timeout: time.Duration(cfg.Timeout)}
+
+	proxy := cfg.Proxy
+	if cfg.Tor && proxy == "" {
+		proxy = defaultTorProxy
+	}
+	if proxy == "" {
+		return client, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if cfg.Tor && proxyURL.Scheme != "socks5" && proxyURL.Scheme != "socks5h" {
+		return nil, fmt.Errorf("--tor needs a SOCKS proxy, got %s", proxy)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Go resolves host names through SOCKS proxies for both socks5 and
+	// socks5h, so DNS lookups do not leak either.
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client.Transport = transport
+
+	if cfg.Tor {
+		client.Transport = &torTransport{base: transport, addr: proxyURL.Host}
+	}
+	return client, nil
+}
+
+// torTransport refuses every request if the Tor proxy cannot be reached. The
+// proxy is checked once, before the first request.
+type torTransport struct {
+	base http.RoundTripper
+	addr string
+
+	once sync.Once
+	err  error
+}
+
+func (t *torTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() {
+		NewLogger(verbose).Debug("Checking Tor proxy at %s", t.addr)
+		conn, err := net.DialTimeout("tcp", t.addr, torCheckTimeout)
+		if err != nil {
+			t.err = fmt.Errorf("tor proxy at %s is not reachable, refusing to connect without it: %w", t.addr, err)
+			return
+		}
+		_ = conn.Close()
+	})
+	if t.err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, t.err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+
+	"github.com/spf13/cobra"
+)
+
+// quoteOptions holds the flags of the quote command.
+type quoteOptions struct {
+	fromNetwork string
+	toNetwork   string
+	fixed       bool
+
+	offerOptions
+}
+
+var quoteOpts quoteOptions
+
+var quoteCmd = &cobra.Command{
+	Use:   "quote <from> <to> <amount>",
+	Short: "Show swap rates without creating a trade",
+	Long: `Quote fetches the offers for swapping amount of one coin into another and
+prints them, ranked as by swap, without creating a trade:
+
+  cyphergoat quote btc xmr 0.5
+
+Each offer shows the effective rate after fees, the value of the payout in
+the --fiat currency, the exchange's limits, its KYC rating and how much less
+it pays than the best offer. Use --output json for a machine-readable list.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		amount, err := strconv.ParseFloat(args[2], 64)
+		if err != nil || amount <= 0 {
+			return fmt.Errorf("invalid amount %q: must be a positive number", args[2])
+		}
+		if err := quoteOpts.offerOptions.apply(); err != nil {
+			return err
+		}
+
+		return runQuote(cmd.Context(), args[0], args[1], amount, quoteOpts)
+	},
+}
+
+func runQuote(ctx context.Context, from, to string, amount float64, opts quoteOptions) error {
+	logger := NewLogger(verbose)
+	out := uiWriter()
+
+	if cfg.APIKey == "" {
+		fmt.Fprintln(out, errorStyle("Error:"), "API key is required")
+		fmt.Fprintln(out)
+		printAPIKeyHelp(out)
+		return errSilent
+	}
+
+	coin1 := strings.ToLower(from)
+	coin2 := strings.ToLower(to)
+	network1 := strings.ToLower(cmp.Or(opts.fromNetwork, cfg.Network(coin1), coin1))
+	network2 := strings.ToLower(cmp.Or(opts.toNetwork, cfg.Network(coin2), coin2))
+	logger.Debug("Fetching rates for %s -> %s (amount: %f, network: %s -> %s)",
+		coin1, coin2, amount, network1, network2)
+
+	request := api.EstimateRequest{
+		Coin1:    coin1,
+		Coin2:    coin2,
+		Amount:   amount,
+		Network1: network1,
+		Network2: network2,
+		Fixed:    opts.fixed,
+	}
+	estimates, hidden, err := fetchEstimates(ctx, request, newRanker(opts.sort, false))
+	if err != nil {
+		logger.Error("Error fetching rates: %s", err)
+		printErrorHint(out, err)
+		return errSilent
+	}
+
+	if machineOutput() {
+		if err := printDocument(newEstimateDocuments(estimates)); err != nil {
+			return err
+		}
+		if len(estimates) == 0 {
+			// Exit non-zero as in table mode, so scripts can tell there
+			// were no offers.
+			return errSilent
+		}
+		return nil
+	}
+
+	if len(estimates) == 0 {
+		printNoOffers(out, hidden, opts.fixed)
+		return errSilent
+	}
+
+	fmt.Fprintln(out, titleStyle(fmt.Sprintf("Quotes for %s %s -> %s",
+		formatLimit(amount), strings.ToUpper(coin1), strings.ToUpper(coin2))))
+	table := newTable(out, []string{"#", "Exchange", "KYC", "You Receive", "Effective Rate", "Value", "Spread", "Rate Type", "Min", "Max"})
+
+	now := time.Now()
+	best := bestReceive(estimates)
+	outOfLimits := false
+	for i, est := range estimates {
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			est.ExchangeName,
+			api.KYCGrade(est.KYCScore),
+			fmt.Sprintf("%.8f %s", est.ReceiveAmount, strings.ToUpper(coin2)),
+			fmt.Sprintf("%.8f", est.Rate()),
+			formatValue(est),
+			formatSpread(spreadPercent(est, best)),
+			rateLabel(est, now),
+			formatLimit(est.MinAmount),
+			formatLimit(est.MaxAmount),
+		}
+		if !est.WithinLimits() {
+			outOfLimits = true
+			for j := range row {
+				row[j] = mutedStyle(row[j])
+			}
+		}
+		table.Append(row)
+	}
+	table.Render()
+	fmt.Fprintln(out, infoStyle(fmt.Sprintf("Effective rates are in %s per %s, after exchange fees. Spread is measured against the largest payout.",
+		strings.ToUpper(coin2), strings.ToUpper(coin1))))
+	if outOfLimits {
+		fmt.Fprintln(out, infoStyle(fmt.Sprintf("Greyed-out offers do not accept this amount; Min and Max are in %s.", strings.ToUpper(coin1))))
+	}
+	if note := hidden.note(); note != "" {
+		fmt.Fprintln(out, infoStyle(note))
+	}
+	printKYCLegend(out)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(quoteCmd)
+
+	quoteCmd.Flags().StringVar(&quoteOpts.fromNetwork, "from-network", "", "Network of the coin to send (defaults to the coin's main chain)")
+	quoteCmd.Flags().StringVar(&quoteOpts.toNetwork, "to-network", "", "Network of the coin to receive (defaults to the coin's main chain)")
+	quoteCmd.Flags().BoolVar(&quoteOpts.fixed, "fixed", false, "Only show fixed-rate offers, which pay exactly the quoted amount")
+	addOfferFlags(quoteCmd, &quoteOpts.offerOptions)
+}
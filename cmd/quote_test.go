@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/api/apitest"
+)
+
+func TestQuote_OnlyAndExclude(t *testing.T) {
+	estimates := []api.Estimate{
+		{ExchangeName: "ChangeNow", ReceiveAmount: 1.5},
+		{ExchangeName: "Exolix", ReceiveAmount: 1.4},
+		{ExchangeName: "FixedFloat", ReceiveAmount: 1.3},
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"only", []string{"--only", "exolix,FixedFloat"}, []string{"Exolix", "FixedFloat"}},
+		{"exclude", []string{"--exclude", "ChangeNow"}, []string{"Exolix", "FixedFloat"}},
+		{"both", []string{"--only", "ChangeNow,Exolix", "--exclude", "exolix"}, []string{"ChangeNow"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := apitest.NewFake()
+			fake.AddEstimates(estimates, nil)
+
+			args := append([]string{"quote", "btc", "xmr", "0.05", "-o", "json"}, tt.args...)
+			stdout, _, err := runCLI(t, fake, args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			var docs []estimateDocument
+			if err := json.Unmarshal([]byte(stdout), &docs); err != nil {
+				t.Fatalf("Expected a JSON document on stdout, got %q: %v", stdout, err)
+			}
+			var got []string
+			for _, doc := range docs {
+				got = append(got, doc.Exchange)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuote_NoOffersFailsInJSON(t *testing.T) {
+	fake := apitest.NewFake()
+	fake.AddEstimates([]api.Estimate{{ExchangeName: "ChangeNow", ReceiveAmount: 1.5}}, nil)
+
+	stdout, _, err := runCLI(t, fake, "quote", "btc", "xmr", "0.05", "-o", "json", "--only", "Exolix")
+	if exitCode(err) == 0 {
+		t.Errorf("Expected a non-zero exit with no offers, got %v", err)
+	}
+	var docs []estimateDocument
+	if err := json.Unmarshal([]byte(stdout), &docs); err != nil || len(docs) != 0 {
+		t.Errorf("Expected an empty list, got %q", stdout)
+	}
+}
+
+func TestQuote_HiddenUnrated(t *testing.T) {
+	fake := apitest.NewFake()
+	fake.AddEstimates([]api.Estimate{
+		{ExchangeName: "ChangeNow", ReceiveAmount: 1.5, KYCScore: 1},
+		{ExchangeName: "Exolix", ReceiveAmount: 1.4, KYCScore: 3},
+		{ExchangeName: "Unrated", ReceiveAmount: 1.6, KYCScore: 0},
+	}, nil)
+
+	stdout, _, err := runCLI(t, fake, "quote", "btc", "xmr", "0.05", "--max-kyc", "B")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	const want = "Hidden offers from exchanges rated worse than KYC B: 1, and from unrated exchanges: 1."
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q, got:\n%s", want, stdout)
+	}
+}
+
+func TestQuote_OnlyUnratedOffers(t *testing.T) {
+	fake := apitest.NewFake()
+	fake.AddEstimates([]api.Estimate{{ExchangeName: "Unrated", ReceiveAmount: 1.6}}, nil)
+
+	stdout, _, err := runCLI(t, fake, "quote", "btc", "xmr", "0.05", "--max-kyc", "D")
+	if exitCode(err) == 0 {
+		t.Errorf("Expected a non-zero exit with no offers, got %v", err)
+	}
+	if !strings.Contains(stdout, "Offers from unrated exchanges left out: 1.") {
+		t.Errorf("Expected the unrated offer to be reported, got:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "Raise --max-kyc") {
+		t.Errorf("Expected no advice to raise --max-kyc, which would not help, got:\n%s", stdout)
+	}
+}
@@ -4,8 +4,11 @@ Copyright © 2025 CypherGoat <contact@cyphergoat.com>
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -15,19 +18,69 @@ var rootCmd = &cobra.Command{
 	Long: `CypherGoat CLI is a tool that helps you perform cryptocurrency swaps from the command line. 
 
 CypherGoat is an instant swap exchange aggregator.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if machineOutput() {
+			color.NoColor = true
+		}
+		if cmd.Annotations[skipConfigAnnotation] != "" {
+			return nil
+		}
+		if err := loadConfig(cmd); err != nil {
+			// A bad setting is not a usage mistake.
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
+	},
 }
 
 var verbose bool
 
+// errSilent is returned by commands that have already reported the failure
+// to the user. It makes the process exit non-zero without printing again.
+var errSilent = errors.New("command failed")
+
+// exitCodeError makes the process exit with a specific status. The outcome
+// has already been reported to the user.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func Execute() {
 	err := rootCmd.Execute()
+	// Let stale prices that were served finish refreshing into the cache,
+	// whether or not the command succeeded.
+	waitForPrices()
 	if err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		if !errors.Is(err, errSilent) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.SilenceErrors = true
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose debug output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format: table, json or yaml")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default $XDG_CONFIG_HOME/cyphergoat/config.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "p", "", "Config profile to use (or set CYPHERGOAT_PROFILE)")
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0, "HTTP request timeout (default 30s)")
+	rootCmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "Proxy URL for all requests (e.g. socks5h://127.0.0.1:9050)")
+	rootCmd.PersistentFlags().BoolVar(&torFlag, "tor", false, "Route all requests through Tor ("+defaultTorProxy+" unless --proxy is set) and refuse to run without it")
+	rootCmd.PersistentFlags().StringVar(&fiatFlag, "fiat", "", "Currency trade values are shown in: usd, eur, chf, gbp, jpy or btc (default usd)")
+	rootCmd.PersistentFlags().IntVar(&retriesFlag, "retries", 0, "Times a failed request is retried (default 2, 0 disables)")
 	rootCmd.AddCommand(NewVersionCmd())
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
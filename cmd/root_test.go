@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/moralpriest/cyphergoat-cli/api/apitest"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runCLI runs the CLI with args against fake, in a fresh home directory and
+// without prompting, and returns what it wrote to stdout and stderr.
+func runCLI(t *testing.T, fake *apitest.Fake, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home+"/config")
+	t.Setenv("XDG_DATA_HOME", home+"/data")
+	t.Setenv("XDG_CACHE_HOME", home+"/cache")
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "CYPHERGOAT_") {
+			t.Setenv(name, "")
+		}
+	}
+	t.Setenv("CYPHERGOAT_API_KEY", "test-key")
+
+	backend = fake
+	color.NoColor = true
+	resetFlags(rootCmd)
+	t.Cleanup(func() {
+		backend = nil
+		priceServices = nil
+	})
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = stdin.Close() }()
+
+	var outBuf, errBuf bytes.Buffer
+	restore := redirect(t, &os.Stdout, &outBuf)
+	restoreErr := redirect(t, &os.Stderr, &errBuf)
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.ExecuteContext(context.Background())
+	waitForPrices()
+
+	os.Stdin = oldStdin
+	restoreErr()
+	restore()
+	return outBuf.String(), errBuf.String(), err
+}
+
+// redirect points *f at a pipe copied into buf until the returned function
+// is called.
+func redirect(t *testing.T, f **os.File, buf *bytes.Buffer) func() {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := *f
+	*f = w
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(buf, r)
+		close(done)
+	}()
+	return func() {
+		*f = old
+		_ = w.Close()
+		<-done
+		_ = r.Close()
+	}
+}
+
+// resetFlags puts every flag of cmd and its subcommands back to its default,
+// since cobra keeps the values of a previous run.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// exitCode returns the status the process would exit with after err.
+func exitCode(err error) int {
+	var exitErr *exitCodeError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	default:
+		return 1
+	}
+}
@@ -4,282 +4,679 @@ Copyright © 2025 CypherGoat <contact@cyphergoat.com>
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/moralpriest/cyphergoat-cli/address"
 	"github.com/moralpriest/cyphergoat-cli/api"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+// swapOptions holds the values that can be supplied on the command line
+// instead of through the interactive prompts.
+type swapOptions struct {
+	from        string
+	to          string
+	fromNetwork string
+	toNetwork   string
+	amount      float64
+	receive     float64
+	address     string
+	exchange    string
+	pick        string
+	yes         bool
+	fixed       bool
+
+	offerOptions
+
+	extraID       string
+	refundAddress string
+	refundMemo    string
+
+	skipAddressCheck bool
+}
+
+var swapOpts swapOptions
+
 var swapCmd = &cobra.Command{
 	Use:   "swap",
 	Short: "Swap cryptocurrencies",
 	Long: `Swap command allows you to perform cryptocurrency swaps between two different coins.
 
-This command uses the CypherGoat API to make the exchange.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		logger := NewLogger(verbose)
-
-		titleStyle := color.New(color.FgCyan, color.Bold).SprintFunc()
-		successStyle := color.New(color.FgGreen, color.Bold).SprintFunc()
-		errorStyle := color.New(color.FgRed, color.Bold).SprintFunc()
-		infoStyle := color.New(color.FgYellow).SprintFunc()
-
-		fmt.Println(titleStyle("CypherGoat Exchange"))
-		fmt.Println()
-
-		answers := struct {
-			CoinFrom    string
-			NetworkFrom string
-			CoinTo      string
-			NetworkTo   string
-			Amount      string
-		}{}
-
-		questions := []*survey.Question{
-			{
-				Name: "CoinFrom",
-				Prompt: &survey.Input{
-					Message: "Send coin:",
-					Help:    "Enter the ticker symbol (e.g., BTC, ETH, SOL)",
-				},
-				Validate: survey.Required,
+This command uses the CypherGoat API to make the exchange.
+
+Every value can be supplied with a flag. Values that are missing are asked
+for interactively, so a fully flagged invocation never prompts:
+
+  cyphergoat swap --from btc --to xmr --amount 0.05 \
+    --address 4... --pick best --yes
+
+To pay an exact amount, give --receive-amount instead of --amount. The
+deposit each exchange needs is then shown and used for the trade:
+
+  cyphergoat swap --from btc --to xmr --receive-amount 1.5`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		if swapOpts.amount < 0 {
+			return fmt.Errorf("--amount must be a positive number")
+		}
+		if swapOpts.receive < 0 {
+			return fmt.Errorf("--receive-amount must be a positive number")
+		}
+		if err := swapOpts.offerOptions.apply(); err != nil {
+			return err
+		}
+		if swapOpts.pick != "" && !strings.EqualFold(swapOpts.pick, "best") {
+			return fmt.Errorf("invalid --pick value %q (supported: best)", swapOpts.pick)
+		}
+
+		return runSwap(cmd.Context(), swapOpts)
+	},
+}
+
+var (
+	titleStyle   = color.New(color.FgCyan, color.Bold).SprintFunc()
+	successStyle = color.New(color.FgGreen, color.Bold).SprintFunc()
+	errorStyle   = color.New(color.FgRed, color.Bold).SprintFunc()
+	infoStyle    = color.New(color.FgYellow).SprintFunc()
+	keyStyle     = color.New(color.FgCyan, color.Bold).SprintFunc()
+	mutedStyle   = color.New(color.FgHiBlack).SprintFunc()
+)
+
+func runSwap(ctx context.Context, opts swapOptions) error {
+	logger := NewLogger(verbose)
+	out := uiWriter()
+
+	fmt.Fprintln(out, titleStyle("CypherGoat Exchange"))
+	fmt.Fprintln(out)
+
+	answers := struct {
+		CoinFrom    string
+		NetworkFrom string
+		CoinTo      string
+		NetworkTo   string
+		Amount      string
+	}{
+		CoinFrom:    opts.from,
+		NetworkFrom: opts.fromNetwork,
+		CoinTo:      opts.to,
+		NetworkTo:   opts.toNetwork,
+	}
+	if opts.amount > 0 {
+		answers.Amount = fmt.Sprintf("%f", opts.amount)
+	}
+
+	// Only ask for the values that were not supplied as flags. A network is
+	// only asked for when its coin is asked for as well, so a fully flagged
+	// invocation falls back to the default network without prompting.
+	var questions []*survey.Question
+	if opts.from == "" {
+		questions = append(questions, &survey.Question{
+			Name: "CoinFrom",
+			Prompt: &survey.Input{
+				Message: "Send coin:",
+				Help:    "Enter the ticker symbol (e.g., BTC, ETH, SOL)",
 			},
-			{
+			Validate: survey.Required,
+		})
+		if opts.fromNetwork == "" {
+			questions = append(questions, &survey.Question{
 				Name: "NetworkFrom",
 				Prompt: &survey.Input{
 					Message: "Send coin network (leave empty for default):",
 					Help:    "Specify network if the asset exists on multiple chains. Leave empty for mainnet (main chain)",
 				},
+			})
+		}
+	}
+	if opts.to == "" {
+		questions = append(questions, &survey.Question{
+			Name: "CoinTo",
+			Prompt: &survey.Input{
+				Message: "Receive coin:",
+				Help:    "Enter the ticker symbol (e.g., BTC, ETH, SOL)",
 			},
-			{
-				Name: "CoinTo",
-				Prompt: &survey.Input{
-					Message: "Receive coin:",
-					Help:    "Enter the ticker symbol (e.g., BTC, ETH, SOL)",
-				},
-				Validate: survey.Required,
-			},
-			{
+			Validate: survey.Required,
+		})
+		if opts.toNetwork == "" {
+			questions = append(questions, &survey.Question{
 				Name: "NetworkTo",
 				Prompt: &survey.Input{
 					Message: "Receive network (leave empty for default):",
 					Help:    "Specify network if the asset exists on multiple chains. Leave empty for mainnet (main chain)",
 				},
+			})
+		}
+	}
+	if answers.Amount == "" && opts.receive == 0 {
+		questions = append(questions, &survey.Question{
+			Name: "Amount",
+			Prompt: &survey.Input{
+				Message: "Amount to swap:",
+				Help:    "Amount of the send coin to exchange",
 			},
-			{
-				Name: "Amount",
-				Prompt: &survey.Input{
-					Message: "Amount to swap:",
-					Help:    fmt.Sprintf("Amount of %s to exchange", strings.ToUpper(answers.CoinFrom)),
-				},
-				Validate: func(ans any) error {
-					strVal, ok := ans.(string)
-					if !ok {
-						return fmt.Errorf("invalid input")
-					}
-
-					var val float64
-					_, err := fmt.Sscanf(strVal, "%f", &val)
-					if err != nil || val <= 0 {
-						return fmt.Errorf("please enter a valid positive number")
-					}
-					return nil
-				},
+			Validate: func(ans any) error {
+				strVal, ok := ans.(string)
+				if !ok {
+					return fmt.Errorf("invalid input")
+				}
+
+				var val float64
+				_, err := fmt.Sscanf(strVal, "%f", &val)
+				if err != nil || val <= 0 {
+					return fmt.Errorf("please enter a valid positive number")
+				}
+				return nil
 			},
-		}
+		})
+	}
 
-		err := survey.Ask(questions, &answers)
-		if err != nil {
-			fmt.Println(errorStyle("Error:"), err)
-			return
+	if len(questions) > 0 {
+		if err := survey.Ask(questions, &answers, surveyOpts()...); err != nil {
+			fmt.Fprintln(out, errorStyle("Error:"), err)
+			return errSilent
 		}
+	}
 
-		// Convert amount to float64 after collecting all inputs
-		var amount float64
-		_, err = fmt.Sscanf(answers.Amount, "%f", &amount)
+	// Convert amount to float64 after collecting all inputs. With
+	// --receive-amount it is only known once an offer is chosen.
+	var amount float64
+	if opts.receive == 0 {
+		_, err := fmt.Sscanf(answers.Amount, "%f", &amount)
 		if err != nil {
-			fmt.Println(errorStyle("Invalid amount:"), err)
-			return
+			fmt.Fprintln(out, errorStyle("Invalid amount:"), err)
+			return errSilent
 		}
-
-		// Process the network inputs
-		if answers.NetworkFrom == "" {
-			answers.NetworkFrom = answers.CoinFrom
+	}
+
+	// Process the network inputs, falling back to the configured default
+	// network for the coin and then to its main chain
+	if answers.NetworkFrom == "" {
+		answers.NetworkFrom = cmp.Or(cfg.Network(answers.CoinFrom), answers.CoinFrom)
+	}
+
+	if answers.NetworkTo == "" {
+		answers.NetworkTo = cmp.Or(cfg.Network(answers.CoinTo), answers.CoinTo)
+	}
+
+	coin1 := strings.ToLower(answers.CoinFrom)
+	coin2 := strings.ToLower(answers.CoinTo)
+	network1 := strings.ToLower(answers.NetworkFrom)
+	network2 := strings.ToLower(answers.NetworkTo)
+
+	// Reject a mistyped --address before anything else happens.
+	if opts.address != "" {
+		if err := checkAddress(coin2, network2, opts.address, opts.skipAddressCheck); err != nil {
+			fmt.Fprintln(out, errorStyle("Error:"), err)
+			fmt.Fprintln(out, infoStyle("Use --skip-address-check if you are sure the address is right."))
+			return errSilent
 		}
-
-		if answers.NetworkTo == "" {
-			answers.NetworkTo = answers.CoinTo
+	}
+	if opts.refundAddress != "" {
+		if err := checkAddress(coin1, network1, opts.refundAddress, opts.skipAddressCheck); err != nil {
+			fmt.Fprintln(out, errorStyle("Error:"), "refund", err)
+			fmt.Fprintln(out, infoStyle("Use --skip-address-check if you are sure the address is right."))
+			return errSilent
 		}
-
-		coin1 := strings.ToLower(answers.CoinFrom)
-		coin2 := strings.ToLower(answers.CoinTo)
-		network1 := strings.ToLower(answers.NetworkFrom)
-		network2 := strings.ToLower(answers.NetworkTo)
-
-		// Check if API key is set before making request
-		if api.GetAPIKey() == "" {
-			fmt.Println(errorStyle("Error:"), "API key is required")
-			fmt.Println()
-			fmt.Println(infoStyle("To set your API key, run one of the following:"))
-			fmt.Println("  export CYPHERGOAT_API_KEY=\"your_api_key_here\"")
-			fmt.Println()
-			fmt.Println(infoStyle("Or add it to your shell config file:"))
-			fmt.Println("  set -gx CYPHERGOAT_API_KEY \"your_api_key_here\"  # for fish shell")
-			fmt.Println()
-			fmt.Println(infoStyle("Get your API key from: https://cyphergoat.com"))
-			return
-		}
-
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = " Fetching Rates from Partnered Exchanges..."
-		_ = s.Color("cyan")
-		s.Start()
-
+	} else if opts.refundMemo != "" {
+		return fmt.Errorf("--refund-memo requires --refund-address")
+	}
+	if err := checkMemo(coin2, network2, opts.extraID); err != nil {
+		return err
+	}
+	if err := checkMemo(coin1, network1, opts.refundMemo); err != nil {
+		return err
+	}
+
+	// Check if API key is set before making request
+	if cfg.APIKey == "" {
+		fmt.Fprintln(out, errorStyle("Error:"), "API key is required")
+		fmt.Fprintln(out)
+		printAPIKeyHelp(out)
+		return errSilent
+	}
+
+	reverse := opts.receive > 0
+	if reverse {
+		logger.Debug("Fetching reverse rates for %s -> %s (receive: %f, network: %s -> %s)",
+			coin1, coin2, opts.receive, network1, network2)
+	} else {
 		logger.Debug("Fetching rates for %s -> %s (amount: %f, network: %s -> %s)",
 			coin1, coin2, amount, network1, network2)
-
-		estimates, err := api.FetchEstimateFromAPI(context.Background(), coin1, coin2, amount, false, network1, network2)
-		s.Stop()
-
-		if err != nil {
-			logger.Error("Error fetching rates: %s", err)
-			if strings.Contains(err.Error(), "API key") {
-				fmt.Println()
-				fmt.Println(infoStyle("Make sure you've set your API key:"))
-				fmt.Println("  export CYPHERGOAT_API_KEY=\"your_api_key_here\"")
-				fmt.Println()
-				fmt.Println(infoStyle("Get your API key from: https://cyphergoat.com"))
+	}
+
+	request := api.EstimateRequest{
+		Coin1:         coin1,
+		Coin2:         coin2,
+		Amount:        amount,
+		Network1:      network1,
+		Network2:      network2,
+		Fixed:         opts.fixed,
+		ReceiveAmount: opts.receive,
+	}
+	ranker := newRanker(opts.sort, reverse)
+	estimates, hidden, err := fetchEstimates(ctx, request, ranker)
+	if err != nil {
+		logger.Error("Error fetching rates: %s", err)
+		printErrorHint(out, err)
+		return errSilent
+	}
+
+	// An amount that every exchange turns down would only fail at /swap, so
+	// offer the smallest amount that some exchange accepts instead.
+	if !reverse && len(estimates) > 0 && !slices.ContainsFunc(estimates, api.Estimate.WithinLimits) {
+		if lowest := api.LowestMinimum(estimates); lowest > amount {
+			if !offerMinimum(out, amount, lowest, coin1, opts.yes) {
+				return errSilent
+			}
+			amount = lowest
+			request.Amount = amount
+			estimates, hidden, err = fetchEstimates(ctx, request, ranker)
+			if err != nil {
+				logger.Error("Error fetching rates: %s", err)
+				printErrorHint(out, err)
+				return errSilent
 			}
-			return
 		}
-
-		if len(estimates) == 0 {
-			fmt.Println(errorStyle("No exchanges available for this trading pair"))
-			return
+	}
+
+	if len(estimates) == 0 {
+		printNoOffers(out, hidden, opts.fixed)
+		return errSilent
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, titleStyle("Available Exchange Options"))
+
+	// Reverse quotes differ in the deposit each exchange needs, so show it.
+	header := []string{"#", "Exchange", "KYC", "You Receive", "Exchange Rate", "Rate Type", "Min", "Max"}
+	if reverse {
+		header = slices.Insert(header, 3, "You Send")
+	}
+	table := newTable(out, header)
+
+	now := time.Now()
+	outOfLimits := false
+	for i, est := range estimates {
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			est.ExchangeName,
+			api.KYCGrade(est.KYCScore),
+			fmt.Sprintf("%.8f %s", est.ReceiveAmount, strings.ToUpper(coin2)),
+			formatValue(est),
+			rateLabel(est, now),
+			formatLimit(est.MinAmount),
+			formatLimit(est.MaxAmount),
 		}
-
-		fmt.Println()
-		fmt.Println(titleStyle("Available Exchange Options"))
-
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"#", "Exchange", "You Receive", "Exchange Rate"})
-		table.SetBorder(false)
-		table.SetHeaderColor(
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
-		)
-
-		for i, est := range estimates {
-			table.Append([]string{
-				fmt.Sprintf("%d", i+1),
-				est.ExchangeName,
-				fmt.Sprintf("%.8f %s", est.ReceiveAmount, strings.ToUpper(coin2)),
-				fmt.Sprintf("$%.2f USD", est.TradeValueUSD),
-			})
+		if reverse {
+			row = slices.Insert(row, 3, fmt.Sprintf("%.8f %s", est.SendAmount, strings.ToUpper(coin1)))
 		}
-		table.Render()
-		fmt.Println()
-
-		var exchangeStr string
-		prompt := &survey.Input{
-			Message: "Select exchange option (enter number):",
+		if !est.WithinLimits() {
+			outOfLimits = true
+			for j := range row {
+				row[j] = mutedStyle(row[j])
+			}
+		}
+		table.Append(row)
+	}
+	table.Render()
+	if outOfLimits {
+		fmt.Fprintln(out, infoStyle(fmt.Sprintf("Greyed-out offers do not accept this amount; Min and Max are in %s.", strings.ToUpper(coin1))))
+	}
+	if note := hidden.note(); note != "" {
+		fmt.Fprintln(out, infoStyle(note))
+	}
+	printKYCLegend(out)
+	fmt.Fprintln(out)
+
+	selected, err := selectEstimate(estimates, opts)
+	if err != nil {
+		fmt.Fprintln(out, errorStyle("Invalid selection:"), err)
+		return errSilent
+	}
+	logger.Debug("Selected exchange: %s", selected.ExchangeName)
+	if reverse {
+		amount = selected.SendAmount
+	}
+
+	receiveAddress := opts.address
+	if receiveAddress == "" {
+		addressPrompt := &survey.Input{
+			Message: fmt.Sprintf("Your %s receiving address:", strings.ToUpper(coin2)),
 		}
 
-		err = survey.AskOne(prompt, &exchangeStr)
+		validate := func(ans any) error {
+			return checkAddress(coin2, network2, fmt.Sprint(ans), opts.skipAddressCheck)
+		}
+		err = survey.AskOne(addressPrompt, &receiveAddress, append(surveyOpts(),
+			survey.WithValidator(survey.Required),
+			survey.WithValidator(validate))...)
 		if err != nil {
-			fmt.Println(errorStyle("Error:"), err)
-			return
+			fmt.Fprintln(out, errorStyle("Error:"), err)
+			return errSilent
 		}
 
-		var selectedExchange int
-		_, err = fmt.Sscanf(exchangeStr, "%d", &selectedExchange)
-		if err != nil || selectedExchange < 1 || selectedExchange > len(estimates) {
-			fmt.Println(errorStyle("Invalid selection:"), "Please select a number between 1 and", len(estimates))
-			return
+		// The memo is asked for along with the address it belongs to.
+		if opts.extraID == "" {
+			extraID, err := askExtraID(coin2, network2)
+			if err != nil {
+				fmt.Fprintln(out, errorStyle("Error:"), err)
+				return errSilent
+			}
+			opts.extraID = extraID
+		}
+	} else if memo, ok := address.MemoFor(coin2, network2); ok && opts.extraID == "" {
+		fmt.Fprintf(out, "%s %s deposits to an exchange or custodial wallet need a %s; pass it with --extra-id.\n",
+			infoStyle("Note:"), strings.ToUpper(coin2), memo.Name)
+	}
+
+	if !opts.yes {
+		confirmed := false
+		confirmPrompt := &survey.Confirm{
+			Message: fmt.Sprintf("Create trade: %.8f %s -> %s via %s?",
+				amount, strings.ToUpper(coin1), strings.ToUpper(coin2), selected.ExchangeName),
+			Default: true,
+		}
+		if err := survey.AskOne(confirmPrompt, &confirmed, surveyOpts()...); err != nil {
+			fmt.Fprintln(out, errorStyle("Error:"), err)
+			return errSilent
 		}
+		if !confirmed {
+			fmt.Fprintln(out, infoStyle("Trade cancelled."))
+			return nil
+		}
+	}
+
+	// A fixed rate is only honored until its quote expires, and the prompts
+	// above may have taken a while.
+	if selected.Fixed() && selected.Expired(time.Now()) {
+		fmt.Fprintln(out, errorStyle("Error:"), "the fixed-rate quote from", selected.ExchangeName, "has expired")
+		fmt.Fprintln(out, infoStyle("Run the swap again for a fresh quote."))
+		return errSilent
+	}
+
+	// Show spinner while creating trade
+	stopSpinner := startSpinner(" Processing transaction...")
+
+	req := api.TradeRequest{
+		Coin1:    coin1,
+		Coin2:    coin2,
+		Amount:   amount,
+		Address:  receiveAddress,
+		Partner:  selected.ExchangeName,
+		Network1: network1,
+		Network2: network2,
+
+		RefundAddress: opts.refundAddress,
+		RefundMemo:    opts.refundMemo,
+		ExtraID:       opts.extraID,
+		RateID:        selected.RateID,
+	}
+	tx, err := backend.CreateTrade(ctx, req)
+	stopSpinner()
+
+	if err != nil {
+		fmt.Fprintln(out, errorStyle("Error creating transaction:"), err)
+		printErrorHint(out, err)
+		return errSilent
+	}
+
+	// The API does not echo the trade parameters back, so fill them in from
+	// the request for anything that reads the transaction later.
+	fillTransaction(&tx, selected, req)
+	saveTrade(tx, receiveAddress)
+
+	if machineOutput() {
+		doc := swapDocument{
+			Estimates:   newEstimateDocuments(estimates),
+			Transaction: newTransactionDocument(tx),
+		}
+		for _, est := range doc.Estimates {
+			if est.Exchange == selected.ExchangeName {
+				doc.Selected = est
+			}
+		}
+		return printDocument(doc)
+	}
+
+	// Display transaction details
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, successStyle("Transaction initiated successfully"))
+	fmt.Fprintln(out)
+
+	// Create details table
+	detailsTable := newDetailsTable(out)
+
+	detailsTable.Append([]string{keyStyle("Amount to Send:"), fmt.Sprintf("%.8f %s", amount, strings.ToUpper(coin1))})
+	detailsTable.Append([]string{keyStyle("Estimated Receive:"), fmt.Sprintf("%.8f %s", tx.EstimateAmount, strings.ToUpper(coin2))})
+	detailsTable.Append([]string{keyStyle("Transaction ID:"), tx.Id})
+	detailsTable.Append([]string{keyStyle("Deposit Address:"), tx.Address})
+	appendMemoDetails(detailsTable, tx)
+	detailsTable.Append([]string{keyStyle("Exchange Provider:"), selected.ExchangeName})
+	detailsTable.Append([]string{keyStyle("Rate Type:"), displayRateType(tx.RateType)})
+	if tx.KYC != "" {
+		detailsTable.Append([]string{keyStyle("KYC Rating:"), displayKYC(tx.KYC)})
+	}
+	detailsTable.Append([]string{keyStyle("Track on cyphergoat.com:"), trackURL(tx)})
+
+	// Add tracking link if available
+	if tx.Track != "" {
+		detailsTable.Append([]string{keyStyle("Transaction Status:"), tx.Track})
+	}
+
+	detailsTable.Render()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, infoStyle("Important: Please send the exact amount to the provided deposit address to complete your transaction."))
+	fmt.Fprintln(out, infoStyle("Follow the trade with:"), "cyphergoat track", tx.Id, "--watch")
+	fmt.Fprintln(out)
+
+	return nil
+}
 
-		selected := estimates[selectedExchange-1]
+// fillTransaction copies the trade parameters onto tx where the API left
+// them empty.
+func fillTransaction(tx *api.Transaction, selected api.Estimate, req api.TradeRequest) {
+	if tx.Coin1 == "" {
+		tx.Coin1 = selected.Coin1
+	}
+	if tx.Coin2 == "" {
+		tx.Coin2 = selected.Coin2
+	}
+	if tx.Network1 == "" {
+		tx.Network1 = selected.Network1
+	}
+	if tx.Network2 == "" {
+		tx.Network2 = selected.Network2
+	}
+	if tx.SendAmount == 0 {
+		tx.SendAmount = selected.SendAmount
+	}
+	if tx.Provider == "" {
+		tx.Provider = selected.ExchangeName
+	}
+	if tx.Status == "" {
+		tx.Status = api.StatusWaiting
+	}
+	if tx.RefundAddress == "" {
+		tx.RefundAddress = req.RefundAddress
+	}
+	if tx.RefundMemo == "" {
+		tx.RefundMemo = req.RefundMemo
+	}
+	if tx.ExtraID == "" {
+		tx.ExtraID = req.ExtraID
+	}
+	if tx.RateType == "" {
+		tx.RateType = selected.RateType
+	}
+	if tx.KYC == "" && selected.KYCScore != 0 {
+		tx.KYC = api.KYCGrade(selected.KYCScore)
+	}
+}
 
-		var address string
-		addressPrompt := &survey.Input{
-			Message: fmt.Sprintf("Your %s receiving address:", strings.ToUpper(coin2)),
+// selectEstimate picks the estimate to trade with. --pick best takes the
+// top-ranked offer from a preferred exchange, falling back to the top-ranked
+// offer overall. --exchange matches a provider by name, and otherwise the
+// user is asked to choose from the table.
+func selectEstimate(estimates []api.Estimate, opts swapOptions) (api.Estimate, error) {
+	if strings.EqualFold(opts.pick, "best") {
+		// Estimates are ranked best first, so the first preferred exchange
+		// with a usable quote is the best preferred offer.
+		usable := slices.DeleteFunc(slices.Clone(estimates), func(est api.Estimate) bool { return !est.WithinLimits() })
+		if len(usable) == 0 {
+			return api.Estimate{}, fmt.Errorf("no exchange accepts this amount")
 		}
-
-		err = survey.AskOne(addressPrompt, &address, survey.WithValidator(survey.Required))
-		if err != nil {
-			fmt.Println(errorStyle("Error:"), err)
-			return
+		for _, est := range usable {
+			if slices.ContainsFunc(cfg.PreferredExchanges, func(name string) bool {
+				return strings.EqualFold(name, est.ExchangeName)
+			}) {
+				return est, nil
+			}
 		}
+		return usable[0], nil
+	}
 
-		// Show spinner while creating trade
-		s.Suffix = " Processing transaction..."
-		s.Start()
+	if opts.exchange != "" {
+		for _, est := range estimates {
+			if strings.EqualFold(est.ExchangeName, opts.exchange) {
+				return est, checkLimits(est)
+			}
+		}
+		return api.Estimate{}, fmt.Errorf("exchange %q did not return a quote for this pair", opts.exchange)
+	}
+
+	var exchangeStr string
+	prompt := &survey.Input{
+		Message: "Select exchange option (enter number):",
+	}
+
+	if err := survey.AskOne(prompt, &exchangeStr, surveyOpts()...); err != nil {
+		return api.Estimate{}, err
+	}
+
+	var selectedExchange int
+	_, err := fmt.Sscanf(exchangeStr, "%d", &selectedExchange)
+	if err != nil || selectedExchange < 1 || selectedExchange > len(estimates) {
+		return api.Estimate{}, fmt.Errorf("please select a number between 1 and %d", len(estimates))
+	}
+
+	est := estimates[selectedExchange-1]
+	return est, checkLimits(est)
+}
 
-		tx, err := api.CreateTradeFromAPI(context.Background(), coin1, coin2, amount, address, selected.ExchangeName, network1, network2)
-		s.Stop()
+// checkLimits explains why an exchange cannot take an estimate's amount.
+func checkLimits(est api.Estimate) error {
+	coin := strings.ToUpper(est.Coin1)
+	switch {
+	case est.WithinLimits():
+		return nil
+	case est.MinAmount > 0 && est.SendAmount < est.MinAmount:
+		return fmt.Errorf("%s requires at least %s %s", est.ExchangeName, formatLimit(est.MinAmount), coin)
+	default:
+		return fmt.Errorf("%s accepts at most %s %s", est.ExchangeName, formatLimit(est.MaxAmount), coin)
+	}
+}
 
-		if err != nil {
-			fmt.Println(errorStyle("Error creating transaction:"), err)
-			return
-		}
+// offerMinimum asks whether to raise an amount that is below every
+// exchange's minimum to the lowest minimum. With --yes there is no one to
+// ask, so it only explains what to do.
+func offerMinimum(out io.Writer, amount, lowest float64, coin string, yes bool) bool {
+	coin = strings.ToUpper(coin)
+	fmt.Fprintf(out, "%s %.8f %s is below every exchange's minimum. The lowest minimum is %s %s.\n",
+		errorStyle("Error:"), amount, coin, formatLimit(lowest), coin)
+	if yes {
+		fmt.Fprintln(out, infoStyle(fmt.Sprintf("Run again with --amount %s.", formatLimit(lowest))))
+		return false
+	}
+
+	bump := false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("Swap %s %s instead?", formatLimit(lowest), coin),
+		Default: true,
+	}
+	if err := survey.AskOne(prompt, &bump, surveyOpts()...); err != nil {
+		fmt.Fprintln(out, errorStyle("Error:"), err)
+		return false
+	}
+	return bump
+}
 
-		// Display transaction details
-		fmt.Println()
-		fmt.Println(successStyle("Transaction initiated successfully"))
-		fmt.Println()
-
-		// Create details table
-		detailsTable := tablewriter.NewWriter(os.Stdout)
-		detailsTable.SetBorder(false)
-		detailsTable.SetAlignment(tablewriter.ALIGN_LEFT)
-		detailsTable.SetHeaderLine(false)
-		detailsTable.SetAutoWrapText(false)
-		detailsTable.SetColumnSeparator(" ")
-
-		// Use colored output directly in the cell content
-		keyStyle := color.New(color.FgCyan, color.Bold).SprintFunc()
-
-		detailsTable.Append([]string{keyStyle("Amount to Send:"), fmt.Sprintf("%.8f %s", amount, strings.ToUpper(coin1))})
-		detailsTable.Append([]string{keyStyle("Estimated Receive:"), fmt.Sprintf("%.8f %s", tx.EstimateAmount, strings.ToUpper(coin2))})
-		detailsTable.Append([]string{keyStyle("Transaction ID:"), tx.Id})
-		detailsTable.Append([]string{keyStyle("Deposit Address:"), tx.Address})
-		detailsTable.Append([]string{keyStyle("Exchange Provider:"), selected.ExchangeName})
-		detailsTable.Append([]string{keyStyle("Track on cyphergoat.com:"), "https://cyphergoat.com/transaction/" + tx.CGID})
-
-		// Add tracking link if available
-		if tx.Track != "" {
-			detailsTable.Append([]string{keyStyle("Transaction Status:"), tx.Track})
-		}
+// checkAddress validates a receive address offline so that funds are not
+// sent to a mistyped or wrong-chain address. Networks without a validator are
+// let through.
+func checkAddress(coin, network, addr string, skip bool) error {
+	if skip {
+		return nil
+	}
+	err := address.Validate(coin, network, addr)
+	if errors.Is(err, address.ErrUnsupported) {
+		NewLogger(verbose).Debug("Not validating address: %s", err)
+		return nil
+	}
+	return err
+}
 
-		detailsTable.Render()
+// checkMemo validates a memo or destination tag offline. Memos on networks
+// the address package knows nothing about are let through.
+func checkMemo(coin, network, memo string) error {
+	err := address.ValidateMemo(coin, network, memo)
+	if errors.Is(err, address.ErrUnsupported) {
+		NewLogger(verbose).Debug("Not validating memo: %s", err)
+		return nil
+	}
+	return err
+}
 
-		fmt.Println()
-		fmt.Println(infoStyle("Important: Please send the exact amount to the provided deposit address to complete your transaction."))
-		fmt.Println()
-	},
+// askExtraID prompts for the memo or destination tag of a receive address on
+// chains that use one. It returns "" without prompting on other chains.
+func askExtraID(coin, network string) (string, error) {
+	memo, ok := address.MemoFor(coin, network)
+	if !ok {
+		return "", nil
+	}
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("%s %s (required by exchanges, leave empty for a personal wallet):",
+			strings.ToUpper(coin), memo.Name),
+	}
+	validate := func(ans any) error {
+		return checkMemo(coin, network, fmt.Sprint(ans))
+	}
+	var extraID string
+	err := survey.AskOne(prompt, &extraID, append(surveyOpts(), survey.WithValidator(validate))...)
+	return extraID, err
 }
 
 func init() {
 	rootCmd.AddCommand(swapCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// swapCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// swapCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	swapCmd.Flags().StringVar(&swapOpts.from, "from", "", "Ticker of the coin to send (e.g. btc)")
+	swapCmd.Flags().StringVar(&swapOpts.to, "to", "", "Ticker of the coin to receive (e.g. xmr)")
+	swapCmd.Flags().StringVar(&swapOpts.fromNetwork, "from-network", "", "Network of the coin to send (defaults to the coin's main chain)")
+	swapCmd.Flags().StringVar(&swapOpts.toNetwork, "to-network", "", "Network of the coin to receive (defaults to the coin's main chain)")
+	swapCmd.Flags().Float64Var(&swapOpts.amount, "amount", 0, "Amount of the send coin to exchange")
+	swapCmd.Flags().StringVar(&swapOpts.address, "address", "", "Address that receives the swapped coins")
+	swapCmd.Flags().StringVar(&swapOpts.exchange, "exchange", "", "Exchange provider to trade with (e.g. ChangeNow)")
+	swapCmd.Flags().StringVar(&swapOpts.pick, "pick", "", "Pick an offer automatically (supported: best)")
+	swapCmd.Flags().BoolVarP(&swapOpts.yes, "yes", "y", false, "Create the trade without asking for confirmation")
+	swapCmd.Flags().Float64Var(&swapOpts.receive, "receive-amount", 0, "Amount of the receive coin to get; the amount to send is worked out per exchange")
+	swapCmd.Flags().BoolVar(&swapOpts.fixed, "fixed", false, "Only show fixed-rate offers, which pay exactly the quoted amount")
+	swapCmd.Flags().StringVar(&swapOpts.extraID, "extra-id", "", "Memo, destination tag or payment ID for the receive address (XRP, XLM, ATOM, ...)")
+	swapCmd.Flags().StringVar(&swapOpts.refundAddress, "refund-address", "", "Address of the send coin to refund to if the swap fails")
+	swapCmd.Flags().StringVar(&swapOpts.refundMemo, "refund-memo", "", "Memo or destination tag for the refund address")
+	addOfferFlags(swapCmd, &swapOpts.offerOptions)
+	swapCmd.Flags().BoolVar(&swapOpts.skipAddressCheck, "skip-address-check", false, "Do not validate the receive address before creating the trade")
+	swapCmd.MarkFlagsMutuallyExclusive("exchange", "pick")
+	swapCmd.MarkFlagsMutuallyExclusive("amount", "receive-amount")
 }
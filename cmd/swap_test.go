@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/api/apitest"
+)
+
+const testXMRAddress = "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A"
+
+func TestSwap_Flagged(t *testing.T) {
+	fake := apitest.NewFake()
+	fake.AddEstimates([]api.Estimate{
+		{ExchangeName: "Exolix", ReceiveAmount: 1.2, KYCScore: 2},
+		{ExchangeName: "ChangeNow", ReceiveAmount: 1.5, KYCScore: 1},
+	}, nil)
+
+	stdout, _, err := runCLI(t, fake, "swap", "--from", "btc", "--to", "xmr", "--amount", "0.05",
+		"--address", testXMRAddress, "--pick", "best", "--yes", "-o", "json")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var doc swapDocument
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatalf("Expected a JSON document on stdout, got %q: %v", stdout, err)
+	}
+	if doc.Selected.Exchange != "ChangeNow" {
+		t.Errorf("Expected the best offer to be picked, got %s", doc.Selected.Exchange)
+	}
+	if doc.Transaction.DepositAddress != "fake-deposit-1" {
+		t.Errorf("Expected the fake's deposit address, got %q", doc.Transaction.DepositAddress)
+	}
+
+	var trade *api.TradeRequest
+	for _, call := range fake.Calls() {
+		if call.Method == apitest.MethodCreateTrade {
+			req := call.Request.(api.TradeRequest)
+			trade = &req
+		}
+	}
+	if trade == nil {
+		t.Fatal("Expected a trade to be created")
+	}
+	if trade.Partner != "ChangeNow" || trade.Amount != 0.05 || trade.Address != testXMRAddress {
+		t.Errorf("Unexpected trade request: %+v", *trade)
+	}
+}
+
+func TestSwap_BelowMinimumWithYes(t *testing.T) {
+	fake := apitest.NewFake()
+	fake.AddEstimates([]api.Estimate{
+		{ExchangeName: "ChangeNow", ReceiveAmount: 0.1, MinAmount: 0.5},
+		{ExchangeName: "Exolix", ReceiveAmount: 0.1, MinAmount: 0.2},
+	}, nil)
+
+	stdout, _, err := runCLI(t, fake, "swap", "--from", "btc", "--to", "xmr", "--amount", "0.01",
+		"--address", testXMRAddress, "--pick", "best", "--yes")
+	if !errors.Is(err, errSilent) {
+		t.Fatalf("Expected the swap to stop, got: %v", err)
+	}
+	if !strings.Contains(stdout, "below every exchange's minimum") || !strings.Contains(stdout, "Run again with --amount 0.2") {
+		t.Errorf("Expected the lowest minimum to be suggested, got:\n%s", stdout)
+	}
+	for _, call := range fake.Calls() {
+		if call.Method == apitest.MethodCreateTrade {
+			t.Errorf("Expected no trade to be created, got %+v", call.Request)
+		}
+	}
+}
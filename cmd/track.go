@@ -0,0 +1,215 @@
+/*
+Copyright © 2025 CypherGoat <contact@cyphergoat.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the track command.
+const (
+	exitTrackFinished = 0
+	exitTrackError    = 1
+	exitTrackFailed   = 2
+	exitTrackRefunded = 3
+)
+
+var (
+	trackWatch    bool
+	trackInterval time.Duration
+)
+
+var trackCmd = &cobra.Command{
+	Use:   "track <id|cgid>",
+	Short: "Show the status of a transaction",
+	Long: `Track fetches a transaction by its provider ID or CypherGoat ID (CGID) and
+prints its status.
+
+With --watch the transaction is polled until it completes, printing every
+status change with a timestamp.
+
+Exit codes:
+  0  the transaction finished (or is still in progress without --watch)
+  1  the transaction could not be fetched
+  2  the transaction failed or expired
+  3  the transaction was refunded`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		if trackInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		return runTrack(ctx, args[0])
+	},
+}
+
+// statusTransition records when a transaction was first seen in a status.
+type statusTransition struct {
+	Status api.TxStatus `json:"status" yaml:"status"`
+	At     time.Time    `json:"at" yaml:"at"`
+}
+
+// trackDocument is emitted by the track command.
+type trackDocument struct {
+	Transaction transactionDocument `json:"transaction" yaml:"transaction"`
+	Transitions []statusTransition  `json:"transitions" yaml:"transitions"`
+}
+
+func runTrack(ctx context.Context, id string) error {
+	logger := NewLogger(verbose)
+	out := uiWriter()
+
+	var (
+		tx          api.Transaction
+		transitions []statusTransition
+	)
+
+	for {
+		logger.Debug("Fetching transaction %s", id)
+
+		stopSpinner := startSpinner(" Fetching transaction...")
+		latest, err := backend.Track(ctx, api.Transaction{Id: id})
+		stopSpinner()
+
+		if err != nil {
+			if ctx.Err() != nil && len(transitions) > 0 {
+				fmt.Fprintln(out, infoStyle("Stopped watching."))
+				return finishTrack(tx, transitions)
+			}
+			fmt.Fprintln(out, errorStyle("Error fetching transaction:"), err)
+			printErrorHint(out, err)
+			return &exitCodeError{code: exitTrackError}
+		}
+		tx = latest
+
+		if len(transitions) == 0 && !machineOutput() {
+			printTransaction(tx)
+		}
+		if len(transitions) == 0 || transitions[len(transitions)-1].Status != tx.Status {
+			t := statusTransition{Status: tx.Status, At: time.Now().UTC()}
+			transitions = append(transitions, t)
+			updateTrade(tx)
+			fmt.Fprintf(out, "[%s] %s %s\n", t.At.Local().Format(time.DateTime), keyStyle("Status:"), displayStatus(tx.Status))
+		}
+
+		if !trackWatch || trackFinished(tx) {
+			return finishTrack(tx, transitions)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out, infoStyle("Stopped watching."))
+			return finishTrack(tx, transitions)
+		case <-time.After(trackInterval):
+		}
+	}
+}
+
+// finishTrack emits the result document in machine mode and maps the final
+// status onto the command's exit code.
+func finishTrack(tx api.Transaction, transitions []statusTransition) error {
+	if machineOutput() {
+		if err := printDocument(trackDocument{
+			Transaction: newTransactionDocument(tx),
+			Transitions: transitions,
+		}); err != nil {
+			return err
+		}
+	}
+
+	switch code := trackExitCode(tx); code {
+	case exitTrackFinished:
+		return nil
+	default:
+		return &exitCodeError{code: code}
+	}
+}
+
+func printTransaction(tx api.Transaction) {
+	out := uiWriter()
+
+	fmt.Fprintln(out)
+	detailsTable := newDetailsTable(out)
+	detailsTable.Append([]string{keyStyle("Transaction ID:"), tx.Id})
+	if tx.Provider != "" {
+		detailsTable.Append([]string{keyStyle("Exchange Provider:"), tx.Provider})
+	}
+	if tx.RateType != "" {
+		detailsTable.Append([]string{keyStyle("Rate Type:"), displayRateType(tx.RateType)})
+	}
+	if tx.KYC != "" {
+		detailsTable.Append([]string{keyStyle("KYC Rating:"), displayKYC(tx.KYC)})
+	}
+	if tx.Coin1 != "" && tx.Coin2 != "" {
+		detailsTable.Append([]string{keyStyle("Pair:"), fmt.Sprintf("%s -> %s", strings.ToUpper(tx.Coin1), strings.ToUpper(tx.Coin2))})
+	}
+	if tx.SendAmount > 0 {
+		detailsTable.Append([]string{keyStyle("Amount to Send:"), fmt.Sprintf("%.8f %s", tx.SendAmount, strings.ToUpper(tx.Coin1))})
+	}
+	if tx.EstimateAmount > 0 {
+		detailsTable.Append([]string{keyStyle("Estimated Receive:"), fmt.Sprintf("%.8f %s", tx.EstimateAmount, strings.ToUpper(tx.Coin2))})
+	}
+	if tx.Address != "" {
+		detailsTable.Append([]string{keyStyle("Deposit Address:"), tx.Address})
+	}
+	appendMemoDetails(detailsTable, tx)
+	if url := trackURL(tx); url != "" {
+		detailsTable.Append([]string{keyStyle("Track on cyphergoat.com:"), url})
+	}
+	detailsTable.Render()
+	fmt.Fprintln(out)
+}
+
+func displayStatus(status api.TxStatus) string {
+	switch {
+	case status == "":
+		return "unknown"
+	case status.Successful():
+		return successStyle(status)
+	case status == api.StatusRefunded:
+		return infoStyle(status)
+	case status.Terminal():
+		return errorStyle(status)
+	default:
+		return status.String()
+	}
+}
+
+// trackFinished reports whether the transaction has reached a status from
+// which it will not move on.
+func trackFinished(tx api.Transaction) bool {
+	return tx.Done || tx.Status.Terminal()
+}
+
+func trackExitCode(tx api.Transaction) int {
+	switch tx.Status {
+	case api.StatusFailed, api.StatusExpired:
+		return exitTrackFailed
+	case api.StatusRefunded:
+		return exitTrackRefunded
+	default:
+		return exitTrackFinished
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(trackCmd)
+
+	trackCmd.Flags().BoolVarP(&trackWatch, "watch", "w", false, "Poll until the transaction completes")
+	trackCmd.Flags().DurationVar(&trackInterval, "interval", 30*time.Second, "Polling interval used with --watch")
+}
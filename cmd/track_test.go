@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/api/apitest"
+)
+
+func TestTrack_ExitCodes(t *testing.T) {
+	tests := []struct {
+		status api.TxStatus
+		want   int
+	}{
+		{api.StatusFinished, exitTrackFinished},
+		{api.StatusWaiting, exitTrackFinished},
+		{api.StatusFailed, exitTrackFailed},
+		{api.StatusExpired, exitTrackFailed},
+		{api.StatusRefunded, exitTrackRefunded},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			fake := apitest.NewFake()
+			fake.AddTransaction(api.Transaction{Id: "tx-1", Coin1: "btc", Coin2: "xmr", Status: tt.status})
+
+			_, _, err := runCLI(t, fake, "track", "tx-1", "-o", "json")
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("Expected exit code %d, got %d (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, _, err := runCLI(t, apitest.NewFake(), "track", "missing", "-o", "json")
+		if got := exitCode(err); got != exitTrackError {
+			t.Errorf("Expected exit code %d, got %d (%v)", exitTrackError, got, err)
+		}
+	})
+}
@@ -12,10 +12,14 @@ func NewLogger(verbose bool) *Logger {
 
 func (l *Logger) Debug(format string, args ...interface{}) {
 	if l.verbose {
-		fmt.Printf("[DEBUG] "+format+"\n", args...)
+		fmt.Fprintf(uiWriter(), "[DEBUG] "+format+"\n", args...)
 	}
 }
 
+func (l *Logger) Warn(format string, args ...interface{}) {
+	fmt.Fprintf(uiWriter(), "[WARN] "+format+"\n", args...)
+}
+
 func (l *Logger) Error(format string, args ...interface{}) {
-	fmt.Printf("[ERROR] "+format+"\n", args...)
+	fmt.Fprintf(uiWriter(), "[ERROR] "+format+"\n", args...)
 }
@@ -18,11 +18,21 @@ func NewVersionCmd() *cobra.Command {
 		Use:   "version",
 		Short: "Print version information",
 		Long:  `Print the version number, build information, and Go runtime version.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if machineOutput() {
+				return printDocument(versionDocument{
+					Version: version,
+					Commit:  commit,
+					Date:    date,
+					Go:      runtime.Version(),
+				})
+			}
+
 			fmt.Printf("CypherGoat CLI %s\n", version)
 			fmt.Printf("Commit: %s\n", commit)
 			fmt.Printf("Date: %s\n", date)
 			fmt.Printf("Go: %s\n", runtime.Version())
+			return nil
 		},
 	}
 }
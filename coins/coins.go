@@ -0,0 +1,236 @@
+// Package coins is the registry of the coins the CLI knows about: their
+// names, CoinGecko and CoinPaprika IDs, networks, decimals, address formats, memos and
+// stablecoin pegs.
+//
+// The registry ships embedded in the binary. "cyphergoat coins update"
+// downloads a newer copy to $XDG_DATA_HOME/cyphergoat/coins.json, and coins
+// listed in $XDG_CONFIG_HOME/cyphergoat/coins.json replace both, so a coin
+// can be added or corrected without waiting for either.
+package coins
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/moralpriest/cyphergoat-cli/internal/xdg"
+)
+
+const fileName = "coins.json"
+
+// formatVersion is the registry file layout this package reads.
+const formatVersion = 1
+
+//go:embed coins.json
+var embedded []byte
+
+// Coin describes one coin.
+type Coin struct {
+	// Ticker is the lower-case symbol the API uses, e.g. "xmr".
+	Ticker string `json:"ticker"`
+	Name   string `json:"name"`
+	// CoinGeckoID is the coin's ID on CoinGecko, used to price it. It is
+	// empty for coins CoinGecko does not list.
+	CoinGeckoID string `json:"coingecko_id,omitempty"`
+	// CoinPaprikaID is the coin's ID on CoinPaprika, e.g. "xmr-monero", or
+	// empty if it is not known.
+	CoinPaprikaID string `json:"coinpaprika_id,omitempty"`
+	// Networks lists the networks the coin can be sent on, its default
+	// first.
+	Networks []string `json:"networks,omitempty"`
+	// Decimals is the number of decimals of the coin's smallest unit.
+	Decimals int `json:"decimals"`
+	// AddressFormat names the family of addresses the coin uses on its
+	// default network, such as "bitcoin" or "evm", when it is known.
+	AddressFormat string `json:"address_format,omitempty"`
+	// Memo is set when deposits to shared addresses need a memo or
+	// destination tag.
+	Memo bool `json:"memo,omitempty"`
+	// Peg is the currency a stablecoin is pegged to, e.g. "usd".
+	Peg string `json:"peg,omitempty"`
+}
+
+// file is the on-disk layout.
+type file struct {
+	Version int    `json:"version"`
+	Coins   []Coin `json:"coins"`
+}
+
+// Registry looks coins up by ticker. A Registry is not modified after it is
+// built, so it is safe for concurrent use.
+type Registry struct {
+	coins map[string]Coin
+}
+
+// Parse reads a registry file.
+func Parse(data []byte) (*Registry, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse coin registry: %w", err)
+	}
+	if f.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported coin registry version %d", f.Version)
+	}
+
+	r := &Registry{coins: make(map[string]Coin, len(f.Coins))}
+	for i, c := range f.Coins {
+		c.Ticker = strings.ToLower(strings.TrimSpace(c.Ticker))
+		if c.Ticker == "" {
+			return nil, fmt.Errorf("coin registry entry %d has no ticker", i+1)
+		}
+		if c.Decimals < 0 {
+			return nil, fmt.Errorf("coin registry entry %s: invalid decimals %d", c.Ticker, c.Decimals)
+		}
+		c.Peg = strings.ToLower(c.Peg)
+		r.coins[c.Ticker] = c
+	}
+	return r, nil
+}
+
+var embeddedRegistry = sync.OnceValue(func() *Registry {
+	r, err := Parse(embedded)
+	if err != nil {
+		panic("coins: embedded registry: " + err.Error())
+	}
+	return r
+})
+
+// Embedded returns the registry built into the binary.
+func Embedded() *Registry {
+	return embeddedRegistry()
+}
+
+var current atomic.Pointer[Registry]
+
+// Default returns the registry set with SetDefault, or the embedded one.
+func Default() *Registry {
+	if r := current.Load(); r != nil {
+		return r
+	}
+	return Embedded()
+}
+
+// SetDefault makes r the registry returned by Default, as the CLI does with
+// the one returned by Load.
+func SetDefault(r *Registry) {
+	current.Store(r)
+}
+
+// Load returns the embedded registry, updated by the last "coins update"
+// and overridden by the user's file, if those exist.
+func Load() (*Registry, error) {
+	r := Embedded()
+
+	updatePath, err := UpdatePath()
+	if err != nil {
+		return nil, err
+	}
+	overridePath, err := OverridePath()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range []string{updatePath, overridePath} {
+		layer, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if layer != nil {
+			r = r.Merge(layer)
+		}
+	}
+	return r, nil
+}
+
+// UpdatePath returns where "coins update" saves the registry.
+func UpdatePath() (string, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// OverridePath returns the location of the user's own registry entries.
+func OverridePath() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// readFile parses the registry at path, or returns nil if there is none.
+func readFile(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coin registry: %w", err)
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Merge returns a registry with the coins of r and other. Coins in other
+// replace those with the same ticker in r.
+func (r *Registry) Merge(other *Registry) *Registry {
+	merged := &Registry{coins: make(map[string]Coin, len(r.coins)+len(other.coins))}
+	for ticker, c := range r.coins {
+		merged.coins[ticker] = c
+	}
+	for ticker, c := range other.coins {
+		merged.coins[ticker] = c
+	}
+	return merged
+}
+
+// Lookup returns the coin with ticker, matched case-insensitively.
+func (r *Registry) Lookup(ticker string) (Coin, bool) {
+	c, ok := r.coins[strings.ToLower(ticker)]
+	return c, ok
+}
+
+// Coins returns every coin, sorted by ticker.
+func (r *Registry) Coins() []Coin {
+	coins := make([]Coin, 0, len(r.coins))
+	for _, c := range r.coins {
+		coins = append(coins, c)
+	}
+	slices.SortFunc(coins, func(a, b Coin) int { return strings.Compare(a.Ticker, b.Ticker) })
+	return coins
+}
+
+// Len returns the number of coins.
+func (r *Registry) Len() int {
+	return len(r.coins)
+}
+
+// CoinGeckoID returns the CoinGecko ID of ticker, or "" if it has none.
+func (r *Registry) CoinGeckoID(ticker string) string {
+	c, _ := r.Lookup(ticker)
+	return c.CoinGeckoID
+}
+
+// CoinPaprikaID returns the CoinPaprika ID of ticker, or "" if it has none.
+func (r *Registry) CoinPaprikaID(ticker string) string {
+	c, _ := r.Lookup(ticker)
+	return c.CoinPaprikaID
+}
+
+// Peg returns the currency ticker is pegged to, or "" if it is not a
+// stablecoin.
+func (r *Registry) Peg(ticker string) string {
+	c, _ := r.Lookup(ticker)
+	return c.Peg
+}
@@ -0,0 +1,130 @@
+package coins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbedded(t *testing.T) {
+	r := Embedded()
+	if r.Len() == 0 {
+		t.Fatal("Expected the embedded registry to list coins")
+	}
+	if got := r.CoinGeckoID("XRP"); got != "ripple" {
+		t.Errorf("Expected xrp to map to ripple, got %q", got)
+	}
+	if got := r.CoinPaprikaID("xmr"); got != "xmr-monero" {
+		t.Errorf("Expected xmr to map to xmr-monero on CoinPaprika, got %q", got)
+	}
+	if got := r.Peg("eurc"); got != "eur" {
+		t.Errorf("Expected eurc to be pegged to eur, got %q", got)
+	}
+	for _, c := range r.Coins() {
+		if c.Name == "" || len(c.Networks) == 0 {
+			t.Errorf("Expected %s to have a name and a network, got %+v", c.Ticker, c)
+		}
+		if c.CoinGeckoID == "" && c.Peg == "" {
+			t.Errorf("Expected %s to be priceable, got %+v", c.Ticker, c)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(`{"version": 1, "coins": [{"ticker": " XNO ", "name": "Nano", "coingecko_id": "nano", "decimals": 30, "peg": "USD"}]}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if c, ok := r.Lookup("xno"); !ok || c.Ticker != "xno" || c.Peg != "usd" {
+		t.Errorf("Expected a normalized entry for xno, got %+v", c)
+	}
+
+	for _, bad := range []string{
+		`not json`,
+		`{"version": 2, "coins": []}`,
+		`{"version": 1, "coins": [{"name": "No ticker"}]}`,
+		`{"version": 1, "coins": [{"ticker": "x", "decimals": -1}]}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Expected error for %s, got nil", bad)
+		}
+	}
+}
+
+func TestLoad_Layers(t *testing.T) {
+	dataHome, configHome := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	write := func(dir, content string) {
+		t.Helper()
+		path := filepath.Join(dir, "cyphergoat", fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(dataHome, `{"version": 1, "coins": [{"ticker": "xno", "name": "Nano", "coingecko_id": "nano"}, {"ticker": "btc", "name": "Bitcoin", "coingecko_id": "from-update"}]}`)
+	write(configHome, `{"version": 1, "coins": [{"ticker": "btc", "name": "Bitcoin", "coingecko_id": "from-user"}]}`)
+
+	r, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := r.CoinGeckoID("btc"); got != "from-user" {
+		t.Errorf("Expected the user's entry to win, got %q", got)
+	}
+	if got := r.CoinGeckoID("xno"); got != "nano" {
+		t.Errorf("Expected the updated registry to add xno, got %q", got)
+	}
+	if got := r.CoinGeckoID("xmr"); got != "monero" {
+		t.Errorf("Expected embedded coins to remain, got %q", got)
+	}
+
+	write(configHome, `{"version": 1, "coins": [{}]}`)
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a broken override file")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	body := `{"version": 1, "coins": [{"ticker": "xno", "name": "Nano", "coingecko_id": "nano"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins.json":
+			fmt.Fprint(w, body)
+		case "/empty.json":
+			fmt.Fprint(w, `{"version": 1, "coins": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), fileName)
+	r, err := Update(context.Background(), server.Client(), server.URL+"/coins.json", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if r.Len() != 1 {
+		t.Errorf("Expected one coin, got %d", r.Len())
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != body {
+		t.Errorf("Expected the download to be saved as is, got %q (%v)", data, err)
+	}
+
+	for _, u := range []string{"/empty.json", "/missing.json"} {
+		if _, err := Update(context.Background(), server.Client(), server.URL+u, path); err == nil {
+			t.Errorf("Expected error for %s, got nil", u)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("Expected a failed update to keep the saved registry, got %q", data)
+	}
+}
@@ -0,0 +1,53 @@
+package coins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/moralpriest/cyphergoat-cli/internal/fileutil"
+)
+
+// DefaultUpdateURL is where "coins update" downloads the registry from.
+const DefaultUpdateURL = "https://raw.githubusercontent.com/moralpriest/cyphergoat-cli/main/coins/coins.json"
+
+// maxDownloadSize bounds a downloaded registry.
+const maxDownloadSize = 4 << 20
+
+// Update downloads the registry at url with client and, if it parses and is
+// not empty, saves it to path. It returns the downloaded registry.
+func Update(ctx context.Context, client *http.Client, url, path string) (*Registry, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download coin registry: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download coin registry: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download coin registry: %w", err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("coin registry is larger than %d bytes", maxDownloadSize)
+	}
+
+	r, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() == 0 {
+		return nil, fmt.Errorf("downloaded coin registry is empty")
+	}
+	if err := fileutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to save coin registry: %w", err)
+	}
+	return r, nil
+}
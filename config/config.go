@@ -0,0 +1,253 @@
+// Package config loads the CLI's configuration file and resolves the
+// effective settings for a profile.
+//
+// The file lives at $XDG_CONFIG_HOME/cyphergoat/config.yaml. Top-level keys
+// apply to every invocation; a named profile under "profiles" overrides them.
+// Environment variables override the file, and command-line flags (applied by
+// the caller) override everything:
+//
+//	flags > environment > profile > top-level settings > defaults
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/internal/fileutil"
+	"github.com/moralpriest/cyphergoat-cli/internal/xdg"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fileName = "config.yaml"
+
+// Settings are the values a profile can hold.
+type Settings struct {
+	APIKey             string             `yaml:"api_key,omitempty"`
+	Networks           map[string]string  `yaml:"networks,omitempty"`
+	PreferredExchanges []string           `yaml:"preferred_exchanges,omitempty"`
+	AllowedExchanges   []string           `yaml:"allowed_exchanges,omitempty"`
+	ExcludedExchanges  []string           `yaml:"excluded_exchanges,omitempty"`
+	Fiat               string             `yaml:"fiat,omitempty"`
+	Timeout            Duration           `yaml:"timeout,omitempty"`
+	Proxy              string             `yaml:"proxy,omitempty"`
+	Tor                bool               `yaml:"tor,omitempty"`
+	APIURL             string             `yaml:"api_url,omitempty"`
+	Retries            *int               `yaml:"retries,omitempty"`
+	RetryBudget        Duration           `yaml:"retry_budget,omitempty"`
+	MaxKYC             string             `yaml:"max_kyc,omitempty"`
+	RankWeights        map[string]float64 `yaml:"rank_weights,omitempty"`
+	PriceSources       []string           `yaml:"price_sources,omitempty"`
+	PriceFile          string             `yaml:"price_file,omitempty"`
+	PriceMedian        bool               `yaml:"price_median,omitempty"`
+	PriceCache         bool               `yaml:"price_cache,omitempty"`
+	PriceCacheTTL      Duration           `yaml:"price_cache_ttl,omitempty"`
+	PriceCacheStale    Duration           `yaml:"price_cache_stale,omitempty"`
+}
+
+// File is the on-disk configuration.
+type File struct {
+	Settings       `yaml:",inline"`
+	DefaultProfile string              `yaml:"default_profile,omitempty"`
+	Profiles       map[string]Settings `yaml:"profiles,omitempty"`
+}
+
+// Defaults returns the settings used when nothing else is configured.
+func Defaults() Settings {
+	retries := 2
+	return Settings{
+		Fiat:          "usd",
+		Timeout:       Duration(30 * time.Second),
+		Retries:       &retries,
+		RetryBudget:   Duration(30 * time.Second),
+		PriceCacheTTL: Duration(5 * time.Minute),
+	}
+}
+
+// Source says where an effective setting came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Duration is a time.Duration written as a Go duration string ("30s").
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", node.Value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultPath returns the location of the configuration file.
+func DefaultPath() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Load reads the configuration file at path. A missing file yields an empty
+// configuration.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Save writes the configuration to path atomically. The file may hold an API
+// key, so it is only readable by the owner.
+func (f *File) Save(path string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// ProfileNames returns the configured profile names, sorted.
+func (f *File) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(f.Profiles))
+}
+
+// Resolved holds the effective settings and where each one came from.
+type Resolved struct {
+	Settings
+	Profile string
+	Sources map[string]Source
+}
+
+// Resolve computes the effective settings for profile. An empty profile falls
+// back to the CYPHERGOAT_PROFILE environment variable and then the file's
+// default_profile. getenv is usually os.Getenv.
+func (f *File) Resolve(profile string, getenv func(string) string) (*Resolved, error) {
+	if profile == "" {
+		profile = getenv(ProfileEnv)
+	}
+	if profile == "" {
+		profile = f.DefaultProfile
+	}
+
+	r := &Resolved{
+		Settings: Defaults(),
+		Profile:  profile,
+		Sources:  make(map[string]Source),
+	}
+	for _, k := range keys {
+		r.Sources[k.name] = SourceDefault
+	}
+
+	if err := r.overlay(f.Settings, SourceFile); err != nil {
+		return nil, err
+	}
+
+	if profile != "" {
+		p, ok := f.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", profile)
+		}
+		if err := r.overlay(p, SourceProfile); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile, err)
+		}
+	}
+
+	for _, k := range keys {
+		if k.env == "" {
+			continue
+		}
+		value := getenv(k.env)
+		if value == "" && k.name == KeyAPIKey {
+			// Kept for compatibility with the original variable name.
+			value = getenv("API_KEY")
+		}
+		if value == "" {
+			continue
+		}
+		if err := k.set(&r.Settings, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", k.env, err)
+		}
+		r.Sources[k.name] = SourceEnv
+	}
+
+	return r, nil
+}
+
+// Override applies a flag value, which takes precedence over every other
+// source.
+func (r *Resolved) Override(name, value string) error {
+	k, ok := lookupKey(name)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", name)
+	}
+	if err := k.set(&r.Settings, value); err != nil {
+		return err
+	}
+	r.Sources[k.name] = SourceFlag
+	return nil
+}
+
+// Network returns the configured default network for coin, or "" if none.
+func (s Settings) Network(coin string) string {
+	return s.Networks[strings.ToLower(coin)]
+}
+
+// overlay copies the fields that are set in s over r.
+func (r *Resolved) overlay(s Settings, source Source) error {
+	for _, k := range keys {
+		value := k.get(s)
+		if value == "" {
+			continue
+		}
+		if err := k.set(&r.Settings, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", k.name, err)
+		}
+		r.Sources[k.name] = source
+	}
+	for coin, network := range s.Networks {
+		if r.Networks == nil {
+			r.Networks = make(map[string]string)
+		}
+		coin = strings.ToLower(coin)
+		r.Networks[coin] = strings.ToLower(network)
+		r.Sources[networkPrefix+coin] = source
+	}
+	return nil
+}
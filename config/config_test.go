@@ -0,0 +1,270 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testEnv(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	file := &File{
+		Settings: Settings{
+			APIKey:   "file-key",
+			Timeout:  Duration(20 * time.Second),
+			Proxy:    "socks5h://127.0.0.1:9050",
+			Networks: map[string]string{"usdt": "eth"},
+		},
+		Profiles: map[string]Settings{
+			"work": {
+				APIKey:   "profile-key",
+				Timeout:  Duration(10 * time.Second),
+				Networks: map[string]string{"USDT": "TRX"},
+			},
+		},
+	}
+
+	r, err := file.Resolve("work", testEnv(map[string]string{"CYPHERGOAT_TIMEOUT": "5s"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := r.Override(KeyTimeout, "3s"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	testCases := []struct {
+		key    string
+		value  string
+		source Source
+	}{
+		{KeyAPIKey, "profile-key", SourceProfile},
+		{KeyTimeout, "3s", SourceFlag},
+		{KeyProxy, "socks5h://127.0.0.1:9050", SourceFile},
+		{KeyFiat, "usd", SourceDefault},
+		{"networks.usdt", "trx", SourceProfile},
+	}
+
+	for _, tc := range testCases {
+		value, err := r.Get(tc.key)
+		if err != nil {
+			t.Fatalf("Get(%s): expected no error, got: %v", tc.key, err)
+		}
+		if value != tc.value {
+			t.Errorf("%s: expected value %q, got %q", tc.key, tc.value, value)
+		}
+		if r.Sources[tc.key] != tc.source {
+			t.Errorf("%s: expected source %s, got %s", tc.key, tc.source, r.Sources[tc.key])
+		}
+	}
+}
+
+func TestResolve_EnvOverridesProfile(t *testing.T) {
+	file := &File{Profiles: map[string]Settings{"work": {APIKey: "profile-key"}}}
+
+	r, err := file.Resolve("", testEnv(map[string]string{
+		ProfileEnv: "work",
+		"API_KEY":  "legacy-key",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if r.Profile != "work" {
+		t.Errorf("Expected profile from environment, got %q", r.Profile)
+	}
+	if r.APIKey != "legacy-key" || r.Sources[KeyAPIKey] != SourceEnv {
+		t.Errorf("Expected API key from legacy env var, got %q from %s", r.APIKey, r.Sources[KeyAPIKey])
+	}
+}
+
+func TestResolve_UnknownProfile(t *testing.T) {
+	file := &File{}
+	if _, err := file.Resolve("missing", testEnv(nil)); err == nil {
+		t.Error("Expected error for unknown profile, got nil")
+	}
+}
+
+func TestResolve_InvalidValues(t *testing.T) {
+	if _, err := (&File{Settings: Settings{Fiat: "doubloons"}}).Resolve("", testEnv(nil)); err == nil {
+		t.Error("Expected error for invalid fiat in file, got nil")
+	}
+	if _, err := (&File{}).Resolve("", testEnv(map[string]string{"CYPHERGOAT_TIMEOUT": "soon"})); err == nil {
+		t.Error("Expected error for invalid timeout in environment, got nil")
+	}
+	if _, err := (&File{}).Resolve("", testEnv(map[string]string{"CYPHERGOAT_PROXY": "ftp://127.0.0.1:21"})); err == nil {
+		t.Error("Expected error for unsupported proxy scheme, got nil")
+	}
+	if _, err := (&File{}).Resolve("", testEnv(map[string]string{"CYPHERGOAT_TOR": "maybe"})); err == nil {
+		t.Error("Expected error for invalid tor flag, got nil")
+	}
+	if _, err := (&File{}).Resolve("", testEnv(map[string]string{"CYPHERGOAT_MAX_KYC": "E"})); err == nil {
+		t.Error("Expected error for invalid KYC rating, got nil")
+	}
+}
+
+func TestSettings_MaxKYCScore(t *testing.T) {
+	var s Settings
+	if err := s.Set(KeyMaxKYC, "2"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if s.MaxKYC != "B" {
+		t.Errorf("Expected score 2 to be stored as B, got %q", s.MaxKYC)
+	}
+}
+
+func TestFile_SetValueAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	file := &File{}
+	if err := file.SetValue("", KeyAPIKey, "top-key"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := file.SetValue("tor", KeyProxy, "socks5h://127.0.0.1:9050"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := file.SetValue("tor", "networks.usdc", "sol"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := file.SetValue("", "colour", "blue"); err == nil {
+		t.Error("Expected error for unknown key, got nil")
+	}
+	if err := file.Save(path); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if loaded.APIKey != "top-key" {
+		t.Errorf("Expected top-level API key, got %q", loaded.APIKey)
+	}
+	tor := loaded.Profiles["tor"]
+	if tor.Proxy != "socks5h://127.0.0.1:9050" || tor.Network("usdc") != "sol" {
+		t.Errorf("Expected tor profile settings to round-trip, got %+v", tor)
+	}
+
+	if err := loaded.SetValue("", KeyAPIKey, ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if loaded.APIKey != "" {
+		t.Errorf("Expected empty value to clear the key, got %q", loaded.APIKey)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing file, got: %v", err)
+	}
+	if file.APIKey != "" || len(file.Profiles) != 0 {
+		t.Errorf("Expected empty config, got %+v", file)
+	}
+}
+
+func TestResolve_RetriesZeroDisables(t *testing.T) {
+	zero := 0
+	file := &File{Settings: Settings{Retries: &zero}}
+
+	r, err := file.Resolve("", testEnv(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if r.Retries == nil || *r.Retries != 0 {
+		t.Errorf("Expected retries 0 from the file, got %v", r.Retries)
+	}
+	if r.Sources[KeyRetries] != SourceFile {
+		t.Errorf("Expected source file, got %s", r.Sources[KeyRetries])
+	}
+
+	if err := r.Override(KeyRetries, "-1"); err == nil {
+		t.Error("Expected an error for a negative retry count")
+	}
+}
+
+func TestSettings_RankWeights(t *testing.T) {
+	var s Settings
+	if err := s.Set(KeyRankWeights, "KYC=1, amount=2"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if s.RankWeights["amount"] != 2 || s.RankWeights["kyc"] != 1 {
+		t.Errorf("Expected amount=2 and kyc=1, got %v", s.RankWeights)
+	}
+	if got, _ := s.Get(KeyRankWeights); got != "amount=2,kyc=1" {
+		t.Errorf("Expected weights in criteria order, got %q", got)
+	}
+
+	for _, bad := range []string{"speed=1", "amount", "amount=-1", "amount=0,kyc=0"} {
+		if err := s.Set(KeyRankWeights, bad); err == nil {
+			t.Errorf("Expected error for %q, got nil", bad)
+		}
+	}
+
+	if err := s.Set(KeyRankWeights, ""); err != nil || s.RankWeights != nil {
+		t.Errorf("Expected empty value to clear weights, got %v (%v)", s.RankWeights, err)
+	}
+}
+
+func TestResolve_ExchangeLists(t *testing.T) {
+	file := &File{Settings: Settings{ExcludedExchanges: []string{"Exolix"}}}
+
+	r, err := file.Resolve("", testEnv(map[string]string{"CYPHERGOAT_ALLOWED_EXCHANGES": "ChangeNow, FixedFloat"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(r.AllowedExchanges) != 2 || r.AllowedExchanges[1] != "FixedFloat" || r.Sources[KeyAllowedExchanges] != SourceEnv {
+		t.Errorf("Expected allow-list from environment, got %v from %s", r.AllowedExchanges, r.Sources[KeyAllowedExchanges])
+	}
+	if got, _ := r.Get(KeyExcludedExchanges); got != "Exolix" || r.Sources[KeyExcludedExchanges] != SourceFile {
+		t.Errorf("Expected deny-list from file, got %q from %s", got, r.Sources[KeyExcludedExchanges])
+	}
+}
+
+func TestSettings_Fiat(t *testing.T) {
+	var s Settings
+	for _, v := range []string{"EUR", "chf", "btc"} {
+		if err := s.Set(KeyFiat, v); err != nil {
+			t.Errorf("Set(%s): expected no error, got: %v", v, err)
+		}
+	}
+	if s.Fiat != "btc" {
+		t.Errorf("Expected btc, got %q", s.Fiat)
+	}
+}
+
+func TestSettings_PriceSources(t *testing.T) {
+	var s Settings
+	if err := s.Set(KeyPriceSources, "CoinGecko, kraken,file"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got, _ := s.Get(KeyPriceSources); got != "coingecko,kraken,file" {
+		t.Errorf("Expected lower-cased sources in order, got %q", got)
+	}
+	if err := s.Set(KeyPriceSources, "coingecko,binance"); err == nil {
+		t.Error("Expected error for an unknown source, got nil")
+	}
+	if err := s.Set(KeyPriceMedian, "yes"); err == nil {
+		t.Error("Expected error for an invalid boolean, got nil")
+	}
+	if err := s.Set(KeyPriceMedian, "true"); err != nil || !s.PriceMedian {
+		t.Errorf("Expected median to be enabled, got %v (%v)", s.PriceMedian, err)
+	}
+}
+
+func TestSettings_PriceCache(t *testing.T) {
+	s := Defaults()
+	if got, _ := s.Get(KeyPriceCacheTTL); got != "5m0s" {
+		t.Errorf("Expected a default TTL of 5m0s, got %q", got)
+	}
+	if err := s.Set(KeyPriceCacheTTL, "0s"); err == nil {
+		t.Error("Expected error for a zero TTL, got nil")
+	}
+	if err := s.Set(KeyPriceCacheStale, "-1m"); err == nil {
+		t.Error("Expected error for a negative stale time, got nil")
+	}
+	if err := s.Set(KeyPriceCacheStale, "1h"); err != nil || time.Duration(s.PriceCacheStale) != time.Hour {
+		t.Errorf("Expected a stale time of 1h, got %v (%v)", s.PriceCacheStale, err)
+	}
+}
@@ -0,0 +1,559 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting names as used by "config get/set" and in the file.
+const (
+	KeyAPIKey             = "api_key"
+	KeyPreferredExchanges = "preferred_exchanges"
+	KeyAllowedExchanges   = "allowed_exchanges"
+	KeyExcludedExchanges  = "excluded_exchanges"
+	KeyFiat               = "fiat"
+	KeyTimeout            = "timeout"
+	KeyProxy              = "proxy"
+	KeyTor                = "tor"
+	KeyAPIURL             = "api_url"
+	KeyRetries            = "retries"
+	KeyRetryBudget        = "retry_budget"
+	KeyMaxKYC             = "max_kyc"
+	KeyRankWeights        = "rank_weights"
+	KeyPriceSources       = "price_sources"
+	KeyPriceFile          = "price_file"
+	KeyPriceMedian        = "price_median"
+	KeyPriceCache         = "price_cache"
+	KeyPriceCacheTTL      = "price_cache_ttl"
+	KeyPriceCacheStale    = "price_cache_stale"
+
+	// networkPrefix introduces a per-coin default network, e.g.
+	// "networks.usdt".
+	networkPrefix = "networks."
+)
+
+// ProfileEnv selects the profile when --profile is not given.
+const ProfileEnv = "CYPHERGOAT_PROFILE"
+
+// RankCriteria lists the criteria rank_weights can weigh, in the order they
+// are written out.
+var RankCriteria = []string{"amount", "value", "kyc", "reliability"}
+
+// PriceSources lists the sources price_sources can name. "file" reads the
+// prices in price_file.
+var PriceSources = []string{"coingecko", "coinpaprika", "kraken", "file"}
+
+// supportedFiat lists the currencies prices can be shown in. Besides fiat
+// currencies, values can be shown in bitcoin.
+var supportedFiat = []string{"usd", "eur", "chf", "gbp", "jpy", "btc"}
+
+type key struct {
+	name string
+	env  string
+	help string
+	get  func(Settings) string
+	set  func(*Settings, string) error
+}
+
+var keys = []key{
+	{
+		name: KeyAPIKey,
+		env:  "CYPHERGOAT_API_KEY",
+		help: "CypherGoat API key",
+		get:  func(s Settings) string { return s.APIKey },
+		set: func(s *Settings, v string) error {
+			s.APIKey = strings.TrimSpace(v)
+			return nil
+		},
+	},
+	{
+		name: KeyPreferredExchanges,
+		env:  "CYPHERGOAT_PREFERRED_EXCHANGES",
+		help: "Comma-separated exchanges preferred by --pick best",
+		get:  func(s Settings) string { return strings.Join(s.PreferredExchanges, ",") },
+		set: func(s *Settings, v string) error {
+			s.PreferredExchanges = splitList(v)
+			return nil
+		},
+	},
+	{
+		name: KeyAllowedExchanges,
+		env:  "CYPHERGOAT_ALLOWED_EXCHANGES",
+		help: "Comma-separated exchanges to get quotes from; empty allows all",
+		get:  func(s Settings) string { return strings.Join(s.AllowedExchanges, ",") },
+		set: func(s *Settings, v string) error {
+			s.AllowedExchanges = splitList(v)
+			return nil
+		},
+	},
+	{
+		name: KeyExcludedExchanges,
+		env:  "CYPHERGOAT_EXCLUDED_EXCHANGES",
+		help: "Comma-separated exchanges never to get quotes from",
+		get:  func(s Settings) string { return strings.Join(s.ExcludedExchanges, ",") },
+		set: func(s *Settings, v string) error {
+			s.ExcludedExchanges = splitList(v)
+			return nil
+		},
+	},
+	{
+		name: KeyFiat,
+		env:  "CYPHERGOAT_FIAT",
+		help: "Currency trade values are shown in",
+		get:  func(s Settings) string { return s.Fiat },
+		set: func(s *Settings, v string) error {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if v == "" {
+				s.Fiat = ""
+				return nil
+			}
+			for _, f := range supportedFiat {
+				if v == f {
+					s.Fiat = v
+					return nil
+				}
+			}
+			return fmt.Errorf("unsupported fiat currency %q (supported: %s)", v, strings.Join(supportedFiat, ", "))
+		},
+	},
+	{
+		name: KeyTimeout,
+		env:  "CYPHERGOAT_TIMEOUT",
+		help: "HTTP request timeout (e.g. 30s, 1m)",
+		get: func(s Settings) string {
+			if s.Timeout == 0 {
+				return ""
+			}
+			return time.Duration(s.Timeout).String()
+		},
+		set: func(s *Settings, v string) error {
+			if v == "" {
+				s.Timeout = 0
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", v)
+			}
+			if d <= 0 {
+				return fmt.Errorf("timeout must be positive")
+			}
+			s.Timeout = Duration(d)
+			return nil
+		},
+	},
+	{
+		name: KeyProxy,
+		env:  "CYPHERGOAT_PROXY",
+		help: "Proxy URL for all outbound requests (e.g. socks5h://127.0.0.1:9050)",
+		get:  func(s Settings) string { return s.Proxy },
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.Proxy = ""
+				return nil
+			}
+			u, err := url.Parse(v)
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("invalid proxy URL %q", v)
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5", "socks5h":
+			default:
+				return fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+			}
+			s.Proxy = v
+			return nil
+		},
+	},
+	{
+		name: KeyTor,
+		env:  "CYPHERGOAT_TOR",
+		help: "Route all requests through Tor and refuse to run without it",
+		get: func(s Settings) string {
+			if !s.Tor {
+				return ""
+			}
+			return "true"
+		},
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.Tor = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			s.Tor = b
+			return nil
+		},
+	},
+	{
+		name: KeyRetries,
+		env:  "CYPHERGOAT_RETRIES",
+		help: "Times a failed request is retried (0 disables retries)",
+		get: func(s Settings) string {
+			if s.Retries == nil {
+				return ""
+			}
+			return strconv.Itoa(*s.Retries)
+		},
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.Retries = nil
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid retry count %q", v)
+			}
+			s.Retries = &n
+			return nil
+		},
+	},
+	{
+		name: KeyRetryBudget,
+		env:  "CYPHERGOAT_RETRY_BUDGET",
+		help: "Total time spent waiting between retries (e.g. 30s)",
+		get: func(s Settings) string {
+			if s.RetryBudget == 0 {
+				return ""
+			}
+			return time.Duration(s.RetryBudget).String()
+		},
+		set: func(s *Settings, v string) error {
+			if v == "" {
+				s.RetryBudget = 0
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", v)
+			}
+			if d <= 0 {
+				return fmt.Errorf("retry budget must be positive")
+			}
+			s.RetryBudget = Duration(d)
+			return nil
+		},
+	},
+	{
+		name: KeyMaxKYC,
+		env:  "CYPHERGOAT_MAX_KYC",
+		help: "Worst KYC rating to get quotes from, A (no KYC) to D",
+		get:  func(s Settings) string { return s.MaxKYC },
+		set: func(s *Settings, v string) error {
+			v = strings.ToUpper(strings.TrimSpace(v))
+			switch v {
+			case "":
+			case "A", "B", "C", "D":
+			case "1", "2", "3", "4":
+				// Scores are stored as the matching letter.
+				v = string(rune('A' + v[0] - '1'))
+			default:
+				return fmt.Errorf("invalid KYC rating %q (use A-D or 1-4)", v)
+			}
+			s.MaxKYC = v
+			return nil
+		},
+	},
+	{
+		name: KeyRankWeights,
+		env:  "CYPHERGOAT_RANK_WEIGHTS",
+		help: "Weights for --sort composite (e.g. amount=0.6,kyc=0.25,reliability=0.15)",
+		get: func(s Settings) string {
+			var pairs []string
+			for _, c := range RankCriteria {
+				if w, ok := s.RankWeights[c]; ok {
+					pairs = append(pairs, c+"="+strconv.FormatFloat(w, 'f', -1, 64))
+				}
+			}
+			return strings.Join(pairs, ",")
+		},
+		set: func(s *Settings, v string) error {
+			weights, err := parseRankWeights(v)
+			if err != nil {
+				return err
+			}
+			s.RankWeights = weights
+			return nil
+		},
+	},
+	{
+		name: KeyPriceSources,
+		env:  "CYPHERGOAT_PRICE_SOURCES",
+		help: "Comma-separated price sources, tried in order (coingecko, coinpaprika, kraken, file)",
+		get:  func(s Settings) string { return strings.Join(s.PriceSources, ",") },
+		set: func(s *Settings, v string) error {
+			sources := splitList(strings.ToLower(v))
+			for _, source := range sources {
+				if !slices.Contains(PriceSources, source) {
+					return fmt.Errorf("unknown price source %q (use %s)", source, strings.Join(PriceSources, ", "))
+				}
+			}
+			s.PriceSources = sources
+			return nil
+		},
+	},
+	{
+		name: KeyPriceFile,
+		env:  "CYPHERGOAT_PRICE_FILE",
+		help: "JSON file of prices for the file price source",
+		get:  func(s Settings) string { return s.PriceFile },
+		set: func(s *Settings, v string) error {
+			s.PriceFile = strings.TrimSpace(v)
+			return nil
+		},
+	},
+	{
+		name: KeyPriceMedian,
+		env:  "CYPHERGOAT_PRICE_MEDIAN",
+		help: "Use the median price across all price sources instead of the first found",
+		get: func(s Settings) string {
+			if !s.PriceMedian {
+				return ""
+			}
+			return "true"
+		},
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.PriceMedian = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			s.PriceMedian = b
+			return nil
+		},
+	},
+	{
+		name: KeyPriceCache,
+		env:  "CYPHERGOAT_PRICE_CACHE",
+		help: "Keep CoinGecko prices in the cache directory between runs",
+		get: func(s Settings) string {
+			if !s.PriceCache {
+				return ""
+			}
+			return "true"
+		},
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.PriceCache = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			s.PriceCache = b
+			return nil
+		},
+	},
+	{
+		name: KeyPriceCacheTTL,
+		env:  "CYPHERGOAT_PRICE_CACHE_TTL",
+		help: "How long cached prices are used (e.g. 5m)",
+		get: func(s Settings) string {
+			if s.PriceCacheTTL == 0 {
+				return ""
+			}
+			return time.Duration(s.PriceCacheTTL).String()
+		},
+		set: func(s *Settings, v string) error {
+			if v == "" {
+				s.PriceCacheTTL = 0
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", v)
+			}
+			if d <= 0 {
+				return fmt.Errorf("price cache TTL must be positive")
+			}
+			s.PriceCacheTTL = Duration(d)
+			return nil
+		},
+	},
+	{
+		name: KeyPriceCacheStale,
+		env:  "CYPHERGOAT_PRICE_CACHE_STALE",
+		help: "How long expired prices are still used while they are refreshed (e.g. 1h)",
+		get: func(s Settings) string {
+			if s.PriceCacheStale == 0 {
+				return ""
+			}
+			return time.Duration(s.PriceCacheStale).String()
+		},
+		set: func(s *Settings, v string) error {
+			if v == "" {
+				s.PriceCacheStale = 0
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", v)
+			}
+			if d < 0 {
+				return fmt.Errorf("price cache stale time must not be negative")
+			}
+			s.PriceCacheStale = Duration(d)
+			return nil
+		},
+	},
+	{
+		name: KeyAPIURL,
+		env:  "CYPHERGOAT_API_URL",
+		help: "CypherGoat API base URL, e.g. a staging host",
+		get:  func(s Settings) string { return s.APIURL },
+		set: func(s *Settings, v string) error {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				s.APIURL = ""
+				return nil
+			}
+			u, err := url.Parse(v)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid API URL %q", v)
+			}
+			s.APIURL = v
+			return nil
+		},
+	},
+}
+
+// KeyInfo describes a setting for help output.
+type KeyInfo struct {
+	Name string
+	Env  string
+	Help string
+}
+
+// Keys lists the settings that can be read and written.
+func Keys() []KeyInfo {
+	infos := make([]KeyInfo, 0, len(keys)+1)
+	for _, k := range keys {
+		infos = append(infos, KeyInfo{Name: k.name, Env: k.env, Help: k.help})
+	}
+	infos = append(infos, KeyInfo{Name: networkPrefix + "<coin>", Help: "Default network for a coin (e.g. networks.usdt=trx)"})
+	return infos
+}
+
+func lookupKey(name string) (key, bool) {
+	for _, k := range keys {
+		if k.name == name {
+			return k, true
+		}
+	}
+	return key{}, false
+}
+
+// Get returns the value of a setting by name.
+func (s Settings) Get(name string) (string, error) {
+	if coin, ok := strings.CutPrefix(name, networkPrefix); ok && coin != "" {
+		return s.Network(coin), nil
+	}
+	k, ok := lookupKey(name)
+	if !ok {
+		return "", fmt.Errorf("unknown config key %q", name)
+	}
+	return k.get(s), nil
+}
+
+// Set changes a setting by name. An empty value clears it.
+func (s *Settings) Set(name, value string) error {
+	if coin, ok := strings.CutPrefix(name, networkPrefix); ok && coin != "" {
+		coin = strings.ToLower(coin)
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			delete(s.Networks, coin)
+			return nil
+		}
+		if s.Networks == nil {
+			s.Networks = make(map[string]string)
+		}
+		s.Networks[coin] = value
+		return nil
+	}
+	k, ok := lookupKey(name)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", name)
+	}
+	return k.set(s, value)
+}
+
+// Profile returns the settings a set/get on the file should act on: the named
+// profile, or the top-level settings when name is empty.
+func (f *File) Profile(name string) *Settings {
+	if name == "" {
+		return &f.Settings
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]Settings)
+	}
+	p := f.Profiles[name]
+	return &p
+}
+
+// SetValue changes a setting in the named profile (or at the top level when
+// profile is empty), creating the profile if needed.
+func (f *File) SetValue(profile, name, value string) error {
+	s := f.Profile(profile)
+	if err := s.Set(name, value); err != nil {
+		return err
+	}
+	if profile != "" {
+		f.Profiles[profile] = *s
+	}
+	return nil
+}
+
+// parseRankWeights reads "criterion=weight" pairs separated by commas.
+// Criteria left out weigh nothing.
+func parseRankWeights(v string) (map[string]float64, error) {
+	items := splitList(v)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	weights := make(map[string]float64, len(items))
+	var total float64
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !slices.Contains(RankCriteria, name) {
+			return nil, fmt.Errorf("invalid rank weight %q (use %s=<weight>)", item, strings.Join(RankCriteria, "|"))
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return nil, fmt.Errorf("invalid weight %q for %s", value, name)
+		}
+		weights[name] = w
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("rank weights must not all be zero")
+	}
+	return weights, nil
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,330 @@
+// Package history keeps a local ledger of the trades created with the CLI so
+// the deposit address, transaction ID and CGID survive a closed terminal.
+//
+// Records are stored as JSON lines in $XDG_DATA_HOME/cyphergoat/history.jsonl.
+// Every write takes an exclusive lock and replaces the file atomically.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/internal/fileutil"
+	"github.com/moralpriest/cyphergoat-cli/internal/xdg"
+)
+
+const fileName = "history.jsonl"
+
+// ErrNotFound is returned when no record matches an ID.
+var ErrNotFound = errors.New("trade not found in history")
+
+// Record is a trade saved in the ledger.
+type Record struct {
+	Transaction    api.Transaction `json:"transaction"`
+	ReceiveAddress string          `json:"receive_address,omitempty"`
+	SavedAt        time.Time       `json:"saved_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// Matches reports whether id is the record's transaction ID or CGID.
+func (r Record) Matches(id string) bool {
+	return id != "" && (r.Transaction.Id == id || r.Transaction.CGID == id)
+}
+
+// Filter selects records. Zero fields match everything.
+type Filter struct {
+	Coin     string
+	Provider string
+	Status   api.TxStatus
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f Filter) match(r Record) bool {
+	tx := r.Transaction
+	if f.Coin != "" && !strings.EqualFold(tx.Coin1, f.Coin) && !strings.EqualFold(tx.Coin2, f.Coin) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(tx.Provider, f.Provider) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(string(tx.Status), string(f.Status)) {
+		return false
+	}
+	if !f.Since.IsZero() && r.SavedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.SavedAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Store is a ledger file on disk.
+type Store struct {
+	path string
+	warn func(error)
+}
+
+// Open returns the store in the XDG data directory.
+func Open() (*Store, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	return NewStore(filepath.Join(dir, fileName)), nil
+}
+
+// NewStore returns a store backed by the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the ledger file location.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SetWarn sets a function told about every line of the ledger that cannot be
+// parsed. Such lines are skipped when reading and kept as they are when the
+// ledger is rewritten, so one damaged trade does not hide the others.
+func (s *Store) SetWarn(warn func(error)) {
+	s.warn = warn
+}
+
+// Append saves a newly created trade.
+func (s *Store) Append(tx api.Transaction, receiveAddress string) (Record, error) {
+	now := time.Now().UTC()
+	record := Record{
+		Transaction:    tx,
+		ReceiveAddress: receiveAddress,
+		SavedAt:        now,
+		UpdatedAt:      now,
+	}
+
+	err := s.modify(func(records []Record) ([]Record, error) {
+		return append(records, record), nil
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// Update replaces the saved transaction matching tx's ID or CGID, keeping the
+// fields the new copy leaves empty. It returns ErrNotFound if the trade was
+// never saved.
+func (s *Store) Update(tx api.Transaction) (Record, error) {
+	var updated Record
+	err := s.modify(func(records []Record) ([]Record, error) {
+		for i := range records {
+			if records[i].Matches(tx.Id) || records[i].Matches(tx.CGID) {
+				records[i].Transaction.Merge(tx)
+				records[i].UpdatedAt = time.Now().UTC()
+				updated = records[i]
+				return records, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return updated, nil
+}
+
+// List returns the records matching f, newest first.
+func (s *Store) List(f Filter) ([]Record, error) {
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk backwards so trades saved within the same instant still come out
+	// newest first after the stable sort.
+	var matched []Record
+	for i := len(records) - 1; i >= 0; i-- {
+		if f.match(records[i]) {
+			matched = append(matched, records[i])
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b Record) int {
+		return b.SavedAt.Compare(a.SavedAt)
+	})
+	return matched, nil
+}
+
+// Get returns the record whose transaction ID or CGID is id.
+func (s *Store) Get(id string) (Record, error) {
+	records, err := s.read()
+	if err != nil {
+		return Record{}, err
+	}
+	for _, r := range records {
+		if r.Matches(id) {
+			return r, nil
+		}
+	}
+	return Record{}, ErrNotFound
+}
+
+// Reliability scores each provider in records by its share of finished
+// trades, for ranking with api.ByReliability. Trades still in progress, and
+// expired ones, where no deposit arrived, say nothing about the provider and
+// are skipped. Scores are smoothed towards 0.5 so that one trade does not
+// decide the ranking, and keyed by lower-cased provider name.
+func Reliability(records []Record) map[string]float64 {
+	type tally struct{ finished, total int }
+	tallies := map[string]*tally{}
+	for _, r := range records {
+		status, err := api.ParseTxStatus(string(r.Transaction.Status))
+		if err != nil || !status.Terminal() || status == api.StatusExpired || r.Transaction.Provider == "" {
+			continue
+		}
+		name := strings.ToLower(r.Transaction.Provider)
+		t, ok := tallies[name]
+		if !ok {
+			t = &tally{}
+			tallies[name] = t
+		}
+		t.total++
+		if status.Successful() {
+			t.finished++
+		}
+	}
+
+	scores := make(map[string]float64, len(tallies))
+	for name, t := range tallies {
+		scores[name] = float64(t.finished+1) / float64(t.total+2)
+	}
+	return scores
+}
+
+// read loads every record. Readers do not take the lock: writes replace the
+// file atomically, so a reader sees either the old or the new ledger.
+func (s *Store) read() ([]Record, error) {
+	records, _, err := s.readAll()
+	return records, err
+}
+
+// readAll loads every record along with the lines that could not be parsed.
+func (s *Store) readAll() ([]Record, []corruptLine, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	records, corrupt, err := decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.warn != nil {
+		for _, c := range corrupt {
+			s.warn(fmt.Errorf("skipped corrupt line %d of %s: %w", c.line, s.path, c.err))
+		}
+	}
+	return records, corrupt, nil
+}
+
+// modify rewrites the ledger under an exclusive lock.
+func (s *Store) modify(fn func([]Record) ([]Record, error)) error {
+	lock, err := fileutil.LockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	records, corrupt, err := s.readAll()
+	if err != nil {
+		return err
+	}
+
+	records, err = fn(records)
+	if err != nil {
+		return err
+	}
+
+	data, err := encode(records, corrupt)
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// corruptLine is a ledger line that is not a valid record.
+type corruptLine struct {
+	line int
+	// after is the number of valid records before the line, which is where
+	// it goes back when the ledger is rewritten.
+	after int
+	text  []byte
+	err   error
+}
+
+func decode(data []byte) ([]Record, []corruptLine, error) {
+	var records []Record
+	var corrupt []corruptLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(text, &r); err != nil {
+			corrupt = append(corrupt, corruptLine{
+				line:  line,
+				after: len(records),
+				text:  bytes.Clone(scanner.Bytes()),
+				err:   err,
+			})
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return records, corrupt, nil
+}
+
+// encode writes records as JSON lines, with the corrupt lines read alongside
+// them put back verbatim in their original places.
+func encode(records []Record, corrupt []corruptLine) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	writeCorrupt := func(upTo int) {
+		for len(corrupt) > 0 && corrupt[0].after <= upTo {
+			buf.Write(corrupt[0].text)
+			buf.WriteByte('\n')
+			corrupt = corrupt[1:]
+		}
+	}
+	for i, r := range records {
+		writeCorrupt(i)
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("failed to encode history record: %w", err)
+		}
+	}
+	// The rest follow the last record, wherever the ledger now ends.
+	writeCorrupt(math.MaxInt)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,244 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+}
+
+func TestStore_AppendAndGet(t *testing.T) {
+	store := newTestStore(t)
+
+	tx := api.Transaction{Id: "tx-1", CGID: "cg-1", Coin1: "btc", Coin2: "xmr", Address: "bc1deposit", Provider: "ChangeNow"}
+	if _, err := store.Append(tx, "4receive"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, id := range []string{"tx-1", "cg-1"} {
+		record, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): expected no error, got: %v", id, err)
+		}
+		if record.Transaction.Address != "bc1deposit" {
+			t.Errorf("Expected deposit address 'bc1deposit', got '%s'", record.Transaction.Address)
+		}
+		if record.ReceiveAddress != "4receive" {
+			t.Errorf("Expected receive address '4receive', got '%s'", record.ReceiveAddress)
+		}
+	}
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestStore_ListEmpty(t *testing.T) {
+	store := newTestStore(t)
+
+	records, err := store.List(Filter{})
+	if err != nil {
+		t.Fatalf("Expected no error for missing file, got: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Expected 0 records, got %d", len(records))
+	}
+}
+
+func TestStore_ListFilter(t *testing.T) {
+	store := newTestStore(t)
+
+	trades := []api.Transaction{
+		{Id: "1", Coin1: "btc", Coin2: "xmr", Provider: "ChangeNow", Status: api.StatusFinished},
+		{Id: "2", Coin1: "eth", Coin2: "btc", Provider: "SimpleSwap", Status: api.StatusWaiting},
+		{Id: "3", Coin1: "ltc", Coin2: "xmr", Provider: "changenow", Status: api.StatusRefunded},
+	}
+	for _, tx := range trades {
+		if _, err := store.Append(tx, ""); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all newest first", Filter{}, []string{"3", "2", "1"}},
+		{"coin either side", Filter{Coin: "BTC"}, []string{"2", "1"}},
+		{"provider case-insensitive", Filter{Provider: "CHANGENOW"}, []string{"3", "1"}},
+		{"status", Filter{Status: api.StatusWaiting}, []string{"2"}},
+		{"since future", Filter{Since: time.Now().Add(time.Hour)}, nil},
+		{"until past", Filter{Until: time.Now().Add(-time.Hour)}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := store.List(tc.filter)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.Transaction.Id)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected %v, got %v", tc.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, err := store.Append(api.Transaction{Id: "tx-1", Address: "deposit", Status: api.StatusWaiting}, "receive"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	record, err := store.Update(api.Transaction{Id: "tx-1", Status: api.StatusFinished, Done: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if record.Transaction.Status != api.StatusFinished || !record.Transaction.Done {
+		t.Errorf("Expected finished and done, got %s done=%v", record.Transaction.Status, record.Transaction.Done)
+	}
+	if record.Transaction.Address != "deposit" {
+		t.Errorf("Expected deposit address to be kept, got '%s'", record.Transaction.Address)
+	}
+
+	if _, err := store.Update(api.Transaction{Id: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestStore_ConcurrentAppend(t *testing.T) {
+	store := newTestStore(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// A separate Store per goroutine mimics separate processes.
+			s := NewStore(store.Path())
+			if _, err := s.Append(api.Transaction{Id: string(rune('a' + i))}, ""); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Append failed: %v", err)
+	}
+
+	records, err := store.List(Filter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(records) != writers {
+		t.Errorf("Expected %d records, got %d", writers, len(records))
+	}
+}
+
+func TestStore_CorruptLine(t *testing.T) {
+	store := newTestStore(t)
+	var warnings []error
+	store.SetWarn(func(err error) { warnings = append(warnings, err) })
+
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if _, err := store.Append(api.Transaction{Id: id, Coin1: "btc", Coin2: "xmr"}, ""); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	const corrupt = `{"transaction": {"id": "tx-bad"`
+	data = []byte(lines[0] + corrupt + "\n" + lines[1])
+	if err := os.WriteFile(store.Path(), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.List(Filter{})
+	if err != nil {
+		t.Fatalf("List: expected no error, got: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Expected 2 records, got %d", len(records))
+	}
+	if _, err := store.Get("tx-2"); err != nil {
+		t.Errorf("Get: expected no error, got: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected a warning per read, got %v", warnings)
+	}
+
+	if _, err := store.Append(api.Transaction{Id: "tx-3"}, ""); err != nil {
+		t.Fatalf("Append: expected no error, got: %v", err)
+	}
+	if _, err := store.Update(api.Transaction{Id: "tx-1", Status: api.StatusFinished}); err != nil {
+		t.Fatalf("Update: expected no error, got: %v", err)
+	}
+
+	data, err = os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d:\n%s", len(lines), data)
+	}
+	if lines[1] != corrupt {
+		t.Errorf("Expected the corrupt line kept in place, got %q", lines[1])
+	}
+	record, err := store.Get("tx-1")
+	if err != nil || record.Transaction.Status != api.StatusFinished {
+		t.Errorf("Expected tx-1 to be updated, got %+v, %v", record, err)
+	}
+}
+
+func TestReliability(t *testing.T) {
+	records := []Record{
+		{Transaction: api.Transaction{Provider: "ChangeNow", Status: api.StatusFinished}},
+		{Transaction: api.Transaction{Provider: "changenow", Status: api.StatusFinished}},
+		{Transaction: api.Transaction{Provider: "ChangeNow", Status: api.StatusRefunded}},
+		{Transaction: api.Transaction{Provider: "ChangeNow", Status: api.StatusWaiting}},
+		{Transaction: api.Transaction{Provider: "FixedFloat", Status: api.StatusFailed}},
+		{Transaction: api.Transaction{Provider: "Exolix", Status: api.StatusExpired}},
+	}
+
+	got := Reliability(records)
+	if len(got) != 2 {
+		t.Fatalf("Expected scores for 2 providers, got %v", got)
+	}
+	// (2 finished + 1) / (3 terminal + 2)
+	if got["changenow"] != 0.6 {
+		t.Errorf("Expected changenow 0.6, got %v", got["changenow"])
+	}
+	if got["fixedfloat"] != 1.0/3 {
+		t.Errorf("Expected fixedfloat 1/3, got %v", got["fixedfloat"])
+	}
+}
@@ -0,0 +1,52 @@
+// Package xdg resolves the per-user directories cyphergoat stores its
+// files in, following the XDG Base Directory specification.
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "cyphergoat"
+
+// DataDir returns $XDG_DATA_HOME/cyphergoat, defaulting to
+// ~/.local/share/cyphergoat (%LOCALAPPDATA%\cyphergoat on Windows).
+func DataDir() (string, error) {
+	return resolve("XDG_DATA_HOME", filepath.Join(".local", "share"), "LOCALAPPDATA")
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/cyphergoat, defaulting to
+// ~/.config/cyphergoat (%APPDATA%\cyphergoat on Windows).
+func ConfigDir() (string, error) {
+	return resolve("XDG_CONFIG_HOME", ".config", "APPDATA")
+}
+
+// CacheDir returns $XDG_CACHE_HOME/cyphergoat, defaulting to
+// ~/.cache/cyphergoat (%LOCALAPPDATA%\cyphergoat\cache on Windows).
+func CacheDir() (string, error) {
+	dir, err := resolve("XDG_CACHE_HOME", ".cache", "LOCALAPPDATA")
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" && os.Getenv("XDG_CACHE_HOME") == "" {
+		dir = filepath.Join(dir, "cache")
+	}
+	return dir, nil
+}
+
+func resolve(xdgEnv, homeRel, windowsEnv string) (string, error) {
+	if dir := os.Getenv(xdgEnv); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv(windowsEnv); dir != "" {
+			return filepath.Join(dir, appName), nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, homeRel, appName), nil
+}
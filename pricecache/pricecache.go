@@ -0,0 +1,130 @@
+// Package pricecache keeps the prices looked up by api.PriceService on disk,
+// so that they outlive a single run of the CLI.
+//
+// Prices are stored as JSON in $XDG_CACHE_HOME/cyphergoat/prices.json. Every
+// write takes an exclusive lock, merges with what other processes saved in
+// the meantime and replaces the file atomically.
+package pricecache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+	"github.com/moralpriest/cyphergoat-cli/internal/fileutil"
+	"github.com/moralpriest/cyphergoat-cli/internal/xdg"
+)
+
+const fileName = "prices.json"
+
+// retention is how long a price is kept after it was fetched. Older prices
+// are dropped on the next write.
+const retention = 7 * 24 * time.Hour
+
+// file is the on-disk layout.
+type file struct {
+	Prices []api.CachedPrice `json:"prices"`
+}
+
+// Store is a price cache file on disk. It implements api.PriceStore.
+type Store struct {
+	path string
+}
+
+// Open returns the store in the XDG cache directory.
+func Open() (*Store, error) {
+	dir, err := xdg.CacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return NewStore(filepath.Join(dir, fileName)), nil
+}
+
+// NewStore returns a store backed by the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the cache file location.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns every stored price. Readers do not take the lock: writes
+// replace the file atomically, so a reader sees either the old or the new
+// cache.
+func (s *Store) Load() ([]api.CachedPrice, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price cache: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse price cache: %w", err)
+	}
+	return f.Prices, nil
+}
+
+// Save adds prices to the cache. A price replaces a stored one for the same
+// coin and currency unless the stored one is newer.
+func (s *Store) Save(prices []api.CachedPrice) error {
+	lock, err := fileutil.LockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	// A corrupt cache is not worth failing over; it is rewritten.
+	stored, _ := s.Load()
+	merged := make(map[[2]string]api.CachedPrice, len(stored)+len(prices))
+	for _, p := range slices.Concat(stored, prices) {
+		key := [2]string{strings.ToLower(p.Coin), strings.ToLower(p.Currency)}
+		if old, ok := merged[key]; ok && old.FetchedAt.After(p.FetchedAt) {
+			continue
+		}
+		merged[key] = p
+	}
+
+	cutoff := time.Now().Add(-retention)
+	f := file{Prices: make([]api.CachedPrice, 0, len(merged))}
+	for _, p := range merged {
+		if p.FetchedAt.After(cutoff) {
+			f.Prices = append(f.Prices, p)
+		}
+	}
+	slices.SortFunc(f.Prices, func(a, b api.CachedPrice) int {
+		return strings.Compare(a.Coin+"/"+a.Currency, b.Coin+"/"+b.Currency)
+	})
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode price cache: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write price cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the cache file.
+func (s *Store) Clear() error {
+	lock, err := fileutil.LockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove price cache: %w", err)
+	}
+	return nil
+}
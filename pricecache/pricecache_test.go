@@ -0,0 +1,93 @@
+package pricecache
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moralpriest/cyphergoat-cli/api"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return NewStore(filepath.Join(t.TempDir(), "prices.json"))
+}
+
+func TestStore_SaveMerges(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if prices, err := store.Load(); err != nil || len(prices) != 0 {
+		t.Fatalf("Expected an empty cache before the first save, got %v (%v)", prices, err)
+	}
+
+	err := store.Save([]api.CachedPrice{
+		{Coin: "btc", Currency: "usd", Price: 64000, FetchedAt: now},
+		{Coin: "eth", Currency: "usd", Price: 3000, FetchedAt: now.Add(-8 * 24 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	err = store.Save([]api.CachedPrice{
+		{Coin: "btc", Currency: "usd", Price: 60000, FetchedAt: now.Add(-time.Minute)},
+		{Coin: "btc", Currency: "eur", Price: 59000, FetchedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	prices, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("Expected btc in two currencies and the old eth price dropped, got %v", prices)
+	}
+	for _, p := range prices {
+		if p.Currency == "usd" && p.Price != 64000 {
+			t.Errorf("Expected the newer USD price to be kept, got %v", p)
+		}
+	}
+}
+
+func TestStore_ConcurrentSaves(t *testing.T) {
+	store := newTestStore(t)
+	coins := []string{"btc", "eth", "xmr", "ltc", "zec", "sol"}
+
+	var wg sync.WaitGroup
+	for _, coin := range coins {
+		wg.Go(func() {
+			// Each save goes through its own Store, as separate processes would.
+			other := NewStore(store.Path())
+			if err := other.Save([]api.CachedPrice{{Coin: coin, Currency: "usd", Price: 1, FetchedAt: time.Now()}}); err != nil {
+				t.Errorf("Save(%s): expected no error, got: %v", coin, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	prices, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(prices) != len(coins) {
+		t.Errorf("Expected every save to be kept, got %d prices", len(prices))
+	}
+}
+
+func TestStore_Clear(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Expected clearing a missing cache to succeed, got: %v", err)
+	}
+	if err := store.Save([]api.CachedPrice{{Coin: "btc", Currency: "usd", Price: 1, FetchedAt: time.Now()}}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if prices, _ := store.Load(); len(prices) != 0 {
+		t.Errorf("Expected an empty cache after clear, got %v", prices)
+	}
+}